@@ -5,6 +5,7 @@ import (
 	"diprec_api/internal/config"
 	"diprec_api/internal/service"
 	course_handler "diprec_api/internal/transport/http/course"
+	group_handler "diprec_api/internal/transport/http/group"
 	"diprec_api/internal/transport/http/middleware"
 	question_handler "diprec_api/internal/transport/http/question"
 	test_handler "diprec_api/internal/transport/http/test"
@@ -47,7 +48,9 @@ func (a *Application) Start(
 	course_handler *course_handler.CourseHandler,
 	test_handler *test_handler.TestHandler,
 	question_handler *question_handler.QuestionHandler,
+	group_handler *group_handler.GroupHandler,
 	auth_service *service.AuthService,
+	access_policy *service.AccessPolicy,
 	internalMW gin.HandlerFunc,
 ) {
 	router := gin.Default()
@@ -73,6 +76,10 @@ func (a *Application) Start(
 
 		protected := v1.Group("")
 		protected.Use(middleware.IsAuthenticated(auth_service, a.logger.Named("Auth Middleware")))
+		courseAccess := middleware.CourseAccess(access_policy, a.logger.Named("Access Middleware"))
+		testAccess := middleware.TestAccess(access_policy, a.logger.Named("Access Middleware"))
+		testAttemptAccess := middleware.TestAttemptAccess(access_policy, a.logger.Named("Access Middleware"))
+		attemptAccess := middleware.AttemptAccess(access_policy, a.logger.Named("Access Middleware"))
 		{
 			user := protected.Group("/user")
 			{
@@ -83,24 +90,68 @@ func (a *Application) Start(
 			{
 				course.GET("", course_handler.Get)
 				course.POST("", middleware.OnlyTeacher(), course_handler.Create)
-				course.GET("/:id", course_handler.GetByID)
+				course.GET("/:id", courseAccess, course_handler.GetByID)
 				course.DELETE("/:id", middleware.OnlyTeacher(), course_handler.Delete)
 				course.PUT("/:id", middleware.OnlyTeacher(), course_handler.Update)
 				course.POST("/:id/enroll", course_handler.Enroll)
+				course.GET("/:id/prerequisites", courseAccess, course_handler.GetPrerequisites)
+				course.PUT("/:id/prerequisites", middleware.OnlyTeacher(), course_handler.SetPrerequisites)
+				course.GET("/:id/groups", middleware.OnlyTeacher(), group_handler.GetByCourse)
+				course.POST("/:id/groups", middleware.OnlyTeacher(), group_handler.Create)
+				course.GET("/:id/schedule", middleware.OnlyTeacher(), courseAccess, test_handler.GetSchedule)
+				course.POST("/:id/tests/import", middleware.OnlyTeacher(), courseAccess, test_handler.Import)
+			}
+
+			group := protected.Group("/group")
+			group.Use(middleware.OnlyTeacher())
+			{
+				group.GET("/:id", group_handler.GetByID)
+				group.DELETE("/:id", group_handler.Delete)
+				group.POST("/:id/users", group_handler.AddUsers)
+				group.DELETE("/:id/users/:userId", group_handler.RemoveUser)
+				group.PUT("/:id/tests/:testId", group_handler.SetTestOverride)
+				group.DELETE("/:id/tests/:testId", group_handler.DeleteTestOverride)
 			}
 
 			test := protected.Group("/test")
 			{
-				test.GET("/:id", test_handler.GetByID)
+				test.GET("/:id", testAccess, test_handler.GetByID)
 				test.POST("/:id" /* middleware.OnlyTeacher(), */, test_handler.Create)
 				test.DELETE("/:id", middleware.OnlyTeacher(), test_handler.Delete)
 				test.PUT("/:id", middleware.OnlyTeacher(), test_handler.Update)
 				test.POST("/:id/question", middleware.OnlyTeacher(), test_handler.AttachQuestion)
+				test.PUT("/:id/questions/order", middleware.OnlyTeacher(), test_handler.ReorderQuestions)
+				test.PATCH("/:id/questions", middleware.OnlyTeacher(), test_handler.BatchQuestions)
+				test.DELETE("/:id/questions/:questionId", middleware.OnlyTeacher(), test_handler.DetachQuestion)
 				test.DELETE("/delete/:testId/:questionId", middleware.OnlyTeacher(), test_handler.DetachQuestion)
 				test.PUT("/:id/start", middleware.OnlyTeacher(), test_handler.StartTest)
 				test.PUT("/:id/stop", middleware.OnlyTeacher(), test_handler.StopTest)
-				test.POST("/:id/begin", test_handler.BeginTest)
-				test.PUT("/:id/finish", test_handler.FinishTest)
+				test.GET("/:id/status-history", middleware.OnlyTeacher(), test_handler.GetStatusHistory)
+				test.POST("/:id/begin", testAttemptAccess, test_handler.BeginTest)
+				test.PUT("/:id/finish", testAccess, test_handler.FinishTest)
+				test.GET("/:id/answers", testAccess, test_handler.GetAnswers)
+				test.GET("/:id/results", middleware.OnlyTeacher(), test_handler.GetResults)
+				test.GET("/:id/stats", middleware.OnlyTeacher(), test_handler.GetStats)
+				test.GET("/:id/prerequisites", testAccess, test_handler.GetPrerequisites)
+				test.PUT("/:id/prerequisites", middleware.OnlyTeacher(), test_handler.SetPrerequisites)
+				test.PUT("/:id/pools", middleware.OnlyTeacher(), test_handler.SetPools)
+				test.POST("/:id/duplicate", middleware.OnlyTeacher(), testAccess, test_handler.Duplicate)
+				test.GET("/:id/export", middleware.OnlyTeacher(), testAccess, test_handler.Export)
+				test.PUT("/:id/practice", middleware.OnlyTeacher(), test_handler.SetPractice)
+				test.GET("/:id/practice", testAccess, test_handler.GetPractice)
+				test.POST("/:id/practice/check", testAccess, test_handler.CheckPractice)
+				test.GET("/:id/extensions", middleware.OnlyTeacher(), test_handler.GetExtensions)
+				test.PUT("/:id/extensions/:userId", middleware.OnlyTeacher(), test_handler.SetExtension)
+				test.DELETE("/:id/extensions/:userId", middleware.OnlyTeacher(), test_handler.DeleteExtension)
+			}
+
+			attempt := protected.Group("/attempt")
+			{
+				attempt.GET("/:id", attemptAccess, test_handler.ResumeAttempt)
+				attempt.PUT("/:id/answers/:questionId", attemptAccess, test_handler.SaveDraft)
+				attempt.POST("/:id/submit", attemptAccess, test_handler.SubmitAttempt)
+				attempt.GET("/:id/review", attemptAccess, test_handler.ReviewAttempt)
+				attempt.GET("/:id/next", attemptAccess, test_handler.NextQuestion)
 			}
 
 			question := protected.Group("/question")
@@ -111,6 +162,7 @@ func (a *Application) Start(
 				question.DELETE("/:id", middleware.OnlyTeacher(), question_handler.Delete)
 				question.PUT("/:id", middleware.OnlyTeacher(), question_handler.Update)
 				question.POST("/:id/check", question_handler.Check)
+				question.GET("/:id/stats", middleware.OnlyTeacher(), question_handler.GetStats)
 			}
 		}
 	}
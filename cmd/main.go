@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"diprec_api/cmd/application"
 	"diprec_api/internal/config"
 	"diprec_api/internal/infrastructure/db/postgres"
 	"diprec_api/internal/infrastructure/kafka"
 	"diprec_api/internal/pkg/logger"
 	"diprec_api/internal/pkg/middleware"
+	"diprec_api/internal/pkg/scheduler"
 	"diprec_api/internal/service"
 	"fmt"
 	"log"
 
+	answer_repo "diprec_api/internal/repository/answer"
+	prerequisite_repo "diprec_api/internal/repository/prerequisite"
+	stats_repo "diprec_api/internal/repository/stats"
+
 	user_repo "diprec_api/internal/repository/user"
 	user_handler "diprec_api/internal/transport/http/user"
 	user_usecase "diprec_api/internal/usecase/user"
@@ -26,6 +32,10 @@ import (
 	question_repo "diprec_api/internal/repository/question"
 	question_handler "diprec_api/internal/transport/http/question"
 	question_usecase "diprec_api/internal/usecase/question"
+
+	group_repo "diprec_api/internal/repository/group"
+	group_handler "diprec_api/internal/transport/http/group"
+	group_usecase "diprec_api/internal/usecase/group"
 )
 
 func main() {
@@ -64,23 +74,62 @@ func main() {
 	fmt.Printf("Kafka producer configured with brokers: %v\n", brokers)
 	internalMW := middleware.Internal(cfg.InternalToken)
 	fmt.Println("Internal token is:", cfg.InternalToken)
+	ar := answer_repo.NewAnswerRepository(db)
+	pr := prerequisite_repo.NewPrerequisiteRepository(db)
+	prerequisite_service := service.NewPrerequisiteService(pr)
+	item_analysis_service := service.NewItemAnalysisService(stats_repo.NewStatsRepository(db), custom_logger)
+
 	ur := user_repo.NewUserRepository(db)
 	uc := user_usecase.NewUserUseCase(ur, auth_service, custom_logger)
 	uh := user_handler.NewUserHandler(uc, custom_logger)
 
 	cr := course_repo.NewCourseRepository(db)
-	cu := course_usecase.NewCourseUseCase(cr, custom_logger)
+	cu := course_usecase.NewCourseUseCase(cr, prerequisite_service, custom_logger)
 	ch := course_handler.NewCourseHandler(cu, custom_logger)
 
 	tr := test_repo.NewTestRepository(db)
-	tu := test_usecase.NewTestUsecase(tr, kp, custom_logger)
+	tu := test_usecase.NewTestUsecase(tr, ar, prerequisite_service, item_analysis_service, kp, test_usecase.Config{
+		AllowClientProgress:     cfg.Tests.AllowClientProgress,
+		TimeLimitGrace:          cfg.Tests.TimeLimitGrace,
+		RecommendationTTL:       cfg.Tests.RecommendationTTL,
+		RecommendationRetention: cfg.Tests.RecommendationRetention,
+	}, custom_logger)
 	th := test_handler.NewTestHandler(tu, custom_logger)
 
+	access_policy := service.NewAccessPolicy(cr, tr)
+
 	qr := question_repo.NewQuestionRepository(db)
-	qu := question_usecase.NewQuestionUsecase(qr, tr, kp, custom_logger)
+	qu := question_usecase.NewQuestionUsecase(qr, tr, ar, access_policy, item_analysis_service, kp, custom_logger, cfg.Tests.TimeLimitGrace)
 	qh := question_handler.NewQuestionHandler(qu, custom_logger)
 
+	gr := group_repo.NewGroupRepository(db)
+	gu := group_usecase.NewGroupUsecase(gr, custom_logger)
+	gh := group_handler.NewGroupHandler(gu, custom_logger)
+
+	jobs := scheduler.New(postgres.NewAdvisoryLocker(db), custom_logger)
+	jobs.Add("finalize-expired-attempts", cfg.Tests.AttemptSweepInterval, func(ctx context.Context) error {
+		_, err := tu.FinalizeExpiredAttempts(ctx)
+		return err
+	})
+	jobs.Add("close-overdue-tests", cfg.Tests.DeadlineSweepInterval, func(ctx context.Context) error {
+		_, err := tu.CloseOverdueTests(ctx)
+		return err
+	})
+	jobs.Add("publish-scheduled-tests", cfg.Tests.PublishSweepInterval, func(ctx context.Context) error {
+		_, err := tu.PublishScheduledTests(ctx)
+		return err
+	})
+	jobs.Add("refresh-item-statistics", cfg.Tests.StatsRefreshInterval, func(ctx context.Context) error {
+		_, err := item_analysis_service.Refresh(ctx)
+		return err
+	})
+	jobs.Add("cleanup-recommended-tests", cfg.Tests.RecommendationSweepInterval, func(ctx context.Context) error {
+		_, err := tu.CleanupRecommendations(ctx)
+		return err
+	})
+	jobs.Start(context.Background())
+
 	app := application.NewApplication(cfg, custom_logger, db)
 
-	app.Start(uh, ch, th, qh, auth_service, internalMW)
+	app.Start(uh, ch, th, qh, gh, auth_service, access_policy, internalMW)
 }
@@ -20,6 +20,7 @@ type Config struct {
 	Auth          AuthConfig    `mapstructure:"auth"`
 	Logging       LoggingConfig `mapstructure:"logging"`
 	KafkaProducer KafkaProducer `mapstructure:"kafka_producer"`
+	Tests         TestsConfig   `mapstructure:"tests"`
 }
 
 type GRPCConfig struct {
@@ -65,6 +66,28 @@ type LoggingConfig struct {
 	}
 }
 
+type TestsConfig struct {
+	// AllowClientProgress разрешает старым клиентам присылать результат
+	// теста самостоятельно. По умолчанию результат считает сервер.
+	AllowClientProgress bool `mapstructure:"allow_client_progress"`
+	// TimeLimitGrace - допуск к лимиту времени попытки на задержки сети.
+	TimeLimitGrace time.Duration `mapstructure:"time_limit_grace"`
+	// AttemptSweepInterval - как часто сдаются просроченные попытки.
+	AttemptSweepInterval time.Duration `mapstructure:"attempt_sweep_interval"`
+	// DeadlineSweepInterval - как часто закрываются тесты с истёкшим дедлайном.
+	DeadlineSweepInterval time.Duration `mapstructure:"deadline_sweep_interval"`
+	// PublishSweepInterval - как часто запускаются тесты по расписанию.
+	PublishSweepInterval time.Duration `mapstructure:"publish_sweep_interval"`
+	// StatsRefreshInterval - как часто пересчитывается статистика вопросов.
+	StatsRefreshInterval time.Duration `mapstructure:"stats_refresh_interval"`
+	// RecommendationTTL - срок жизни теста рекомендации по умолчанию.
+	RecommendationTTL time.Duration `mapstructure:"recommendation_ttl"`
+	// RecommendationRetention - сколько хранится завершённый тест рекомендации.
+	RecommendationRetention time.Duration `mapstructure:"recommendation_retention"`
+	// RecommendationSweepInterval - как часто удаляются устаревшие тесты рекомендаций.
+	RecommendationSweepInterval time.Duration `mapstructure:"recommendation_sweep_interval"`
+}
+
 type KafkaProducer struct {
 	Broker string `mapstructure:"broker"`
 }
@@ -137,4 +160,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.rotation_policy.max_size", 100) // MB
 	v.SetDefault("logging.rotation_policy.max_backups", 3)
 	v.SetDefault("logging.rotation_policy.max_age", 30) // days
+
+	// Tests defaults
+	v.SetDefault("tests.allow_client_progress", false)
+	v.SetDefault("tests.time_limit_grace", 30*time.Second)
+	v.SetDefault("tests.attempt_sweep_interval", time.Minute)
+	v.SetDefault("tests.deadline_sweep_interval", time.Minute)
+	v.SetDefault("tests.publish_sweep_interval", 30*time.Second)
+	v.SetDefault("tests.stats_refresh_interval", 15*time.Minute)
+	v.SetDefault("tests.recommendation_ttl", 14*24*time.Hour)
+	v.SetDefault("tests.recommendation_retention", 72*time.Hour)
+	v.SetDefault("tests.recommendation_sweep_interval", time.Hour)
 }
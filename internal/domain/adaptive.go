@@ -0,0 +1,200 @@
+package domain
+
+import "math"
+
+// Оценка способности по модели Раша: вероятность верного ответа на вопрос
+// сложности b при способности θ равна 1 / (1 + e^(b-θ)). Способность
+// оценивается по максимуму апостериорной вероятности с априорным N(0, 1),
+// поэтому оценка конечна и при одних верных или одних неверных ответах.
+const (
+	maxAbility        = 4
+	abilityIterations = 25
+	abilityTolerance  = 1e-6
+)
+
+// AbilityEstimate - оценка способности студента, её стандартная ошибка и
+// число ответов, по которым она получена.
+type AbilityEstimate struct {
+	Ability       float64
+	StandardError float64
+	Items         int
+}
+
+// Percent переводит способность в результат теста в процентах: вероятность
+// верного ответа на вопрос средней сложности.
+func (e AbilityEstimate) Percent() uint {
+	return uint(math.Round(100 * correctProbability(e.Ability, 0)))
+}
+
+// ItemDifficulty - сложность вопроса на шкале способности: 1-5 переводятся
+// в -2..2, незаданная считается средней.
+func (c *Question) ItemDifficulty() float64 {
+	if c.Difficulty == 0 {
+		return 0
+	}
+	return float64(c.Difficulty) - 3
+}
+
+func correctProbability(ability, difficulty float64) float64 {
+	return 1 / (1 + math.Exp(difficulty-ability))
+}
+
+// EstimateAbility оценивает способность по ответам на questions. Доля
+// полученных баллов за ответ считается наблюдаемым исходом, вопрос без
+// ответа - неверным.
+func EstimateAbility(questions []*Question, answers []*UserTestAnswer) AbilityEstimate {
+	credit := make(map[uint]float64, len(answers))
+	for _, answer := range answers {
+		credit[answer.QuestionID] = answer.Credit()
+	}
+
+	information := func(ability float64) (float64, float64) {
+		gradient, info := -ability, 1.0
+		for _, question := range questions {
+			p := correctProbability(ability, question.ItemDifficulty())
+			gradient += credit[question.ID] - p
+			info += p * (1 - p)
+		}
+		return gradient, info
+	}
+
+	var ability float64
+	for i := 0; i < abilityIterations; i++ {
+		gradient, info := information(ability)
+		step := gradient / info
+		ability = math.Max(-maxAbility, math.Min(maxAbility, ability+step))
+		if math.Abs(step) < abilityTolerance {
+			break
+		}
+	}
+
+	_, info := information(ability)
+	return AbilityEstimate{
+		Ability:       round2(ability),
+		StandardError: round2(1 / math.Sqrt(info)),
+		Items:         len(questions),
+	}
+}
+
+// IsAdaptive сообщает, выдаются ли вопросы теста адаптивно.
+func (c *Test) IsAdaptive() bool {
+	return c.Adaptive != nil && *c.Adaptive
+}
+
+// UsesAttemptQuestions сообщает, фиксируются ли вопросы за попыткой: при
+// выборке из пулов или адаптивной выдаче.
+func (c *Test) UsesAttemptQuestions() bool {
+	return c.UsesPools() || c.IsAdaptive()
+}
+
+// AdaptiveStopped - сработало ли правило остановки адаптивного теста:
+// выдано AdaptiveMaxItems вопросов, достигнута точность AdaptiveTargetSE
+// или вопросы закончились.
+func (c *Test) AdaptiveStopped(estimate AbilityEstimate, remaining int) bool {
+	if remaining == 0 {
+		return true
+	}
+	if c.AdaptiveMaxItems > 0 && uint(estimate.Items) >= c.AdaptiveMaxItems {
+		return true
+	}
+	return c.AdaptiveTargetSE > 0 && estimate.Items > 0 && estimate.StandardError <= c.AdaptiveTargetSE
+}
+
+// NextAdaptiveQuestion выбирает среди candidates самый информативный при
+// способности ability вопрос - со сложностью ближе всего к ней. При равной
+// сложности выигрывает стоящий раньше.
+func NextAdaptiveQuestion(candidates []*Question, ability float64) *Question {
+	var next *Question
+	best := math.Inf(1)
+	for _, question := range candidates {
+		if distance := math.Abs(question.ItemDifficulty() - ability); distance < best {
+			best = distance
+			next = question
+		}
+	}
+	return next
+}
+
+// AdaptiveProgress разбирает вопросы адаптивной попытки: served - выданные
+// по порядку, answers - проверенные ответы. Возвращает вопросы с ответом,
+// выданный, но ещё не отвеченный вопрос, и оставшиеся невыданными вопросы
+// теста.
+func (c *Test) AdaptiveProgress(served []uint, answers []*UserTestAnswer) (answered []*Question, pending *Question, remaining []*Question) {
+	byID := make(map[uint]*Question, len(c.Questions))
+	for _, question := range c.Questions {
+		byID[question.ID] = question
+	}
+	hasAnswer := make(map[uint]bool, len(answers))
+	for _, answer := range answers {
+		hasAnswer[answer.QuestionID] = true
+	}
+
+	isServed := make(map[uint]bool, len(served))
+	for _, id := range served {
+		isServed[id] = true
+		question, ok := byID[id]
+		if !ok {
+			continue
+		}
+		if hasAnswer[id] {
+			answered = append(answered, question)
+		} else if pending == nil {
+			pending = question
+		}
+	}
+
+	for _, question := range c.Questions {
+		if !isServed[question.ID] {
+			remaining = append(remaining, question)
+		}
+	}
+
+	return answered, pending, remaining
+}
+
+// AdaptiveStep - результат запроса следующего вопроса адаптивной попытки.
+// Question == nil - тест остановлен, а попытка завершена с результатом Result.
+type AdaptiveStep struct {
+	Attempt  *Attempt
+	Question *Question
+	Number   int
+	Estimate AbilityEstimate
+	Result   *UserTests
+}
+
+type AdaptiveStepResponse struct {
+	Finished bool `json:"finished"`
+	// Number - номер выданного вопроса в попытке, с 1.
+	Number        int               `json:"number,omitempty"`
+	Question      *QuestionResponse `json:"question,omitempty"`
+	Ability       float64           `json:"ability"`
+	StandardError float64           `json:"standardError"`
+	Answered      int               `json:"answered"`
+	Result        *UserTestResponse `json:"result,omitempty"`
+}
+
+func (s *AdaptiveStep) ToAdaptiveStepResponse() AdaptiveStepResponse {
+	response := AdaptiveStepResponse{
+		Finished:      s.Question == nil,
+		Number:        s.Number,
+		Ability:       s.Estimate.Ability,
+		StandardError: s.Estimate.StandardError,
+		Answered:      s.Estimate.Items,
+	}
+	if s.Question != nil {
+		question := s.Question.ToQuestionResponse(false)
+		response.Question = &question
+	}
+	if s.Result != nil {
+		result := s.Result.ToUserTestResponse()
+		response.Result = &result
+	}
+
+	return response
+}
+
+// SetAbility сохраняет в попытке оценку способности.
+func (a *Attempt) SetAbility(estimate AbilityEstimate) {
+	a.Ability = &estimate.Ability
+	a.AbilitySE = &estimate.StandardError
+}
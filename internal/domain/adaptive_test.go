@@ -0,0 +1,139 @@
+package domain
+
+import "testing"
+
+func adaptiveItems(difficulties ...uint) []*Question {
+	questions := make([]*Question, len(difficulties))
+	for i, difficulty := range difficulties {
+		questions[i] = &Question{ID: uint(i + 1), Difficulty: difficulty}
+	}
+	return questions
+}
+
+func adaptiveAnswers(credits ...float64) []*UserTestAnswer {
+	answers := make([]*UserTestAnswer, len(credits))
+	for i, credit := range credits {
+		answers[i] = &UserTestAnswer{QuestionID: uint(i + 1), Score: credit, MaxScore: 1, IsCorrect: credit == 1}
+	}
+	return answers
+}
+
+func TestEstimateAbility(t *testing.T) {
+	t.Run("no answers is the prior", func(t *testing.T) {
+		got := EstimateAbility(nil, nil)
+		if got.Ability != 0 || got.StandardError != 1 || got.Items != 0 {
+			t.Errorf("EstimateAbility() = %+v, want prior N(0, 1)", got)
+		}
+		if got.Percent() != 50 {
+			t.Errorf("Percent() = %d, want 50", got.Percent())
+		}
+	})
+
+	t.Run("all correct and all wrong are finite and symmetric", func(t *testing.T) {
+		items := adaptiveItems(3, 3, 3, 3, 3)
+		high := EstimateAbility(items, adaptiveAnswers(1, 1, 1, 1, 1))
+		low := EstimateAbility(items, adaptiveAnswers(0, 0, 0, 0, 0))
+
+		if high.Ability <= 0 || high.Ability >= maxAbility {
+			t.Errorf("all correct ability = %v, want in (0, %d)", high.Ability, maxAbility)
+		}
+		if high.Ability != -low.Ability || high.StandardError != low.StandardError {
+			t.Errorf("all correct %+v is not symmetric to all wrong %+v", high, low)
+		}
+	})
+
+	t.Run("half correct on average items is average", func(t *testing.T) {
+		got := EstimateAbility(adaptiveItems(3, 3), adaptiveAnswers(1, 0))
+		if got.Ability != 0 {
+			t.Errorf("ability = %v, want 0", got.Ability)
+		}
+	})
+
+	t.Run("harder items answered give higher ability", func(t *testing.T) {
+		easy := EstimateAbility(adaptiveItems(1, 1, 1), adaptiveAnswers(1, 1, 0))
+		hard := EstimateAbility(adaptiveItems(5, 5, 5), adaptiveAnswers(1, 1, 0))
+		if hard.Ability <= easy.Ability {
+			t.Errorf("hard items ability %v <= easy items ability %v", hard.Ability, easy.Ability)
+		}
+	})
+
+	t.Run("partial credit is between wrong and correct", func(t *testing.T) {
+		items := adaptiveItems(3)
+		wrong := EstimateAbility(items, adaptiveAnswers(0))
+		partial := EstimateAbility(items, adaptiveAnswers(0.5))
+		correct := EstimateAbility(items, adaptiveAnswers(1))
+		if !(wrong.Ability < partial.Ability && partial.Ability < correct.Ability) {
+			t.Errorf("abilities wrong %v, partial %v, correct %v are not ordered", wrong.Ability, partial.Ability, correct.Ability)
+		}
+	})
+
+	t.Run("unanswered item counts as wrong", func(t *testing.T) {
+		items := adaptiveItems(3, 3)
+		unanswered := EstimateAbility(items, adaptiveAnswers(1))
+		wrong := EstimateAbility(items, adaptiveAnswers(1, 0))
+		if unanswered != wrong {
+			t.Errorf("unanswered %+v != wrong %+v", unanswered, wrong)
+		}
+	})
+
+	t.Run("standard error shrinks with more items", func(t *testing.T) {
+		few := EstimateAbility(adaptiveItems(3, 3), adaptiveAnswers(1, 0))
+		many := EstimateAbility(adaptiveItems(3, 3, 3, 3, 3, 3), adaptiveAnswers(1, 0, 1, 0, 1, 0))
+		if many.StandardError >= few.StandardError || many.Items != 6 {
+			t.Errorf("SE with 6 items %v >= SE with 2 items %v", many.StandardError, few.StandardError)
+		}
+	})
+}
+
+func TestTestAdaptiveStopped(t *testing.T) {
+	tests := []struct {
+		name      string
+		test      Test
+		estimate  AbilityEstimate
+		remaining int
+		want      bool
+	}{
+		{"no items left", Test{}, AbilityEstimate{Items: 1, StandardError: 0.9}, 0, true},
+		{"no stop rule", Test{}, AbilityEstimate{Items: 20, StandardError: 0.2}, 5, false},
+		{"max items reached", Test{AdaptiveMaxItems: 5}, AbilityEstimate{Items: 5, StandardError: 0.9}, 5, true},
+		{"below max items", Test{AdaptiveMaxItems: 5}, AbilityEstimate{Items: 4, StandardError: 0.9}, 5, false},
+		{"target precision reached", Test{AdaptiveTargetSE: 0.5}, AbilityEstimate{Items: 6, StandardError: 0.5}, 5, true},
+		{"target precision not reached", Test{AdaptiveTargetSE: 0.5}, AbilityEstimate{Items: 6, StandardError: 0.51}, 5, false},
+		{"precision needs at least one answer", Test{AdaptiveTargetSE: 1}, AbilityEstimate{StandardError: 1}, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.test.AdaptiveStopped(tt.estimate, tt.remaining); got != tt.want {
+				t.Errorf("AdaptiveStopped() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextAdaptiveQuestion(t *testing.T) {
+	items := adaptiveItems(1, 0, 4, 5, 4)
+
+	tests := []struct {
+		name    string
+		ability float64
+		wantID  uint
+	}{
+		{"average ability takes unset difficulty", 0, 2},
+		{"low ability", -3, 1},
+		{"tie goes to the earlier question", 1.2, 3},
+		{"ability above the bank", maxAbility, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextAdaptiveQuestion(items, tt.ability); got == nil || got.ID != tt.wantID {
+				t.Errorf("NextAdaptiveQuestion(%v) = %+v, want question %d", tt.ability, got, tt.wantID)
+			}
+		})
+	}
+
+	if got := NextAdaptiveQuestion(nil, 0); got != nil {
+		t.Errorf("NextAdaptiveQuestion(nil) = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"diprec_api/internal/pkg/utils"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// UserAnswer - запись о каждом ответе, отправленном студентом на проверку.
+// Записи не изменяются и служат историей для оценивания, аналитики и
+// апелляций. TestID и AttemptID равны нулю, если ответ дан вне попытки.
+type UserAnswer struct {
+	ID         uint           `gorm:"primaryKey;autoIncrement"`
+	UserID     uint           `gorm:"not null;index"`
+	TestID     uint           `gorm:"not null;default:0;index"`
+	AttemptID  uint           `gorm:"not null;default:0;index"`
+	QuestionID uint           `gorm:"not null;index"`
+	Answer     datatypes.JSON `gorm:"type:jsonb"`
+	IsCorrect  bool           `gorm:"not null;default:false"`
+	Score      float64        `gorm:"not null;default:0"`
+	MaxScore   float64        `gorm:"not null;default:0"`
+	CreatedAt  time.Time      `gorm:"index"`
+}
+
+type UserAnswerResponse struct {
+	ID         uint        `json:"id"`
+	UserID     uint        `json:"userId"`
+	TestID     uint        `json:"testId"`
+	AttemptID  uint        `json:"attemptId"`
+	QuestionID uint        `json:"questionId"`
+	Answer     interface{} `json:"answer"`
+	IsCorrect  bool        `json:"isCorrect"`
+	Score      float64     `json:"score"`
+	MaxScore   float64     `json:"maxScore"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
+
+func (a *UserAnswer) ToUserAnswerResponse() UserAnswerResponse {
+	return UserAnswerResponse{
+		ID:         a.ID,
+		UserID:     a.UserID,
+		TestID:     a.TestID,
+		AttemptID:  a.AttemptID,
+		QuestionID: a.QuestionID,
+		Answer:     utils.ParseJSONInterface(a.Answer),
+		IsCorrect:  a.IsCorrect,
+		Score:      a.Score,
+		MaxScore:   a.MaxScore,
+		CreatedAt:  a.CreatedAt,
+	}
+}
+
+func ToUserAnswersResponse(answers []*UserAnswer) []UserAnswerResponse {
+	responses := make([]UserAnswerResponse, len(answers))
+	for i, answer := range answers {
+		responses[i] = answer.ToUserAnswerResponse()
+	}
+	return responses
+}
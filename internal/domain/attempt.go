@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Attempt - одна попытка прохождения теста студентом. Итоговый результат
+// в UserTests собирается из завершённых попыток по ScoringPolicy теста.
+type Attempt struct {
+	ID         uint           `gorm:"primaryKey;autoIncrement"`
+	TestID     uint           `gorm:"not null;index:idx_attempts_test_user"`
+	UserID     uint           `gorm:"not null;index:idx_attempts_test_user"`
+	Number     uint           `gorm:"not null"`
+	Status     UserTestStatus `gorm:"type:varchar(20);not null;default:'IN_PROGRESS'"`
+	Score      uint           `gorm:"not null;default:0"` // результат в процентах
+	Points     float64        `gorm:"not null;default:0"`
+	MaxPoints  float64        `gorm:"not null;default:0"`
+	StartedAt  time.Time      `gorm:"not null"`
+	ExpiresAt  *time.Time     `gorm:"index"`
+	FinishedAt *time.Time
+	// Late и LatePenalty - попытка сдана после дедлайна и на сколько
+	// процентов за это снижен результат.
+	Late        bool `gorm:"not null;default:false"`
+	LatePenalty uint `gorm:"not null;default:0"`
+	// Ability и AbilitySE - оценка способности в адаптивном тесте и её
+	// стандартная ошибка.
+	Ability   *float64
+	AbilitySE *float64
+}
+
+type AttemptResponse struct {
+	ID          uint       `json:"id"`
+	Number      uint       `json:"number"`
+	Status      string     `json:"status"`
+	Score       uint       `json:"score"`
+	Points      float64    `json:"points"`
+	MaxPoints   float64    `json:"maxPoints"`
+	Late        bool       `json:"late,omitempty"`
+	LatePenalty uint       `json:"latePenalty,omitempty"`
+	Ability     *float64   `json:"ability,omitempty"`
+	AbilitySE   *float64   `json:"abilitySE,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (a *Attempt) ToAttemptResponse() AttemptResponse {
+	return AttemptResponse{
+		ID:          a.ID,
+		Number:      a.Number,
+		Status:      a.Status.String(),
+		Score:       a.Score,
+		Points:      a.Points,
+		MaxPoints:   a.MaxPoints,
+		Late:        a.Late,
+		LatePenalty: a.LatePenalty,
+		Ability:     a.Ability,
+		AbilitySE:   a.AbilitySE,
+		StartedAt:   a.StartedAt,
+		ExpiresAt:   a.ExpiresAt,
+		FinishedAt:  a.FinishedAt,
+	}
+}
+
+func ToAttemptsResponse(attempts []*Attempt) []AttemptResponse {
+	responses := make([]AttemptResponse, len(attempts))
+	for i, attempt := range attempts {
+		responses[i] = attempt.ToAttemptResponse()
+	}
+	return responses
+}
+
+// RefreshExpiry пересчитывает срок попытки по текущим лимиту времени и
+// дедлайну test: продление или перенос дедлайна группы после начала попытки
+// сдвигает и её окончание. Дедлайн студента должен быть подставлен в test.
+func (a *Attempt) RefreshExpiry(test *Test) {
+	a.ExpiresAt = test.AttemptExpiry(a.StartedAt)
+}
+
+// IsExpired - время на попытку вышло с учётом допуска grace на задержки сети.
+func (a *Attempt) IsExpired(now time.Time, grace time.Duration) bool {
+	return a.ExpiresAt != nil && now.After(a.ExpiresAt.Add(grace))
+}
+
+// RemainingSeconds - сколько секунд осталось до конца попытки, не меньше
+// нуля. nil, если время попытки не ограничено.
+func (a *Attempt) RemainingSeconds(now time.Time) *int64 {
+	if a.ExpiresAt == nil {
+		return nil
+	}
+
+	remaining := int64(a.ExpiresAt.Sub(now).Seconds())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+type ScoringPolicy string
+
+const (
+	ScoreBest    ScoringPolicy = "BEST"
+	ScoreLast    ScoringPolicy = "LAST"
+	ScoreAverage ScoringPolicy = "AVERAGE"
+)
+
+func (p ScoringPolicy) String() string {
+	return string(p)
+}
+
+// Aggregate сводит результаты завершённых попыток в итоговый результат теста.
+func (p ScoringPolicy) Aggregate(attempts []*Attempt) uint {
+	var finished []*Attempt
+	for _, attempt := range attempts {
+		if attempt.Status == Completed {
+			finished = append(finished, attempt)
+		}
+	}
+	if len(finished) == 0 {
+		return 0
+	}
+
+	switch p {
+	case ScoreBest:
+		var best uint
+		for _, attempt := range finished {
+			if attempt.Score > best {
+				best = attempt.Score
+			}
+		}
+		return best
+
+	case ScoreAverage:
+		var sum uint
+		for _, attempt := range finished {
+			sum += attempt.Score
+		}
+		return uint(math.Round(float64(sum) / float64(len(finished))))
+
+	default:
+		last := finished[0]
+		for _, attempt := range finished {
+			if attempt.Number > last.Number {
+				last = attempt
+			}
+		}
+		return last.Score
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoringPolicyAggregate(t *testing.T) {
+	attempts := []*Attempt{
+		{Number: 1, Status: Completed, Score: 60},
+		{Number: 3, Status: Completed, Score: 75},
+		{Number: 2, Status: Completed, Score: 90},
+		{Number: 4, Status: InProgress, Score: 100},
+	}
+
+	tests := []struct {
+		name     string
+		policy   ScoringPolicy
+		attempts []*Attempt
+		want     uint
+	}{
+		{"best", ScoreBest, attempts, 90},
+		{"last by number, not by order", ScoreLast, attempts, 75},
+		{"average rounds", ScoreAverage, attempts, 75},
+		{"average rounds half up", ScoreAverage, []*Attempt{{Number: 1, Status: Completed, Score: 50}, {Number: 2, Status: Completed, Score: 51}}, 51},
+		{"unknown policy falls back to last", "", attempts, 75},
+		{"open attempt is ignored", ScoreBest, []*Attempt{{Number: 1, Status: InProgress, Score: 100}}, 0},
+		{"no attempts", ScoreAverage, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Aggregate(tt.attempts); got != tt.want {
+				t.Errorf("%s.Aggregate() = %d, want %d", tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserTestsHasCompletedAttempt(t *testing.T) {
+	tests := []struct {
+		name string
+		ut   UserTests
+		want bool
+	}{
+		{"first attempt open", UserTests{Status: InProgress, Attempt: 1}, false},
+		{"completed", UserTests{Status: Completed, Attempt: 1}, true},
+		{"retake open", UserTests{Status: InProgress, Attempt: 2}, true},
+		{"recommendation not started", UserTests{Status: New}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ut.HasCompletedAttempt(); got != tt.want {
+				t.Errorf("HasCompletedAttempt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttemptRefreshExpiry(t *testing.T) {
+	started := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	test := &Test{ID: 1, TimeLimit: 60, Deadline: started.Add(30 * time.Minute)}
+	attempt := &Attempt{StartedAt: started, ExpiresAt: test.AttemptExpiry(started)}
+
+	if !attempt.ExpiresAt.Equal(started.Add(30 * time.Minute)) {
+		t.Fatalf("ExpiresAt before extension = %v, want the deadline", attempt.ExpiresAt)
+	}
+
+	test.ApplyExtension(&TestExtension{TestID: 1, Deadline: started.Add(2 * time.Hour)})
+	attempt.RefreshExpiry(test)
+
+	if !attempt.ExpiresAt.Equal(started.Add(time.Hour)) {
+		t.Errorf("ExpiresAt after extension = %v, want the time limit", attempt.ExpiresAt)
+	}
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"diprec_api/internal/pkg/utils"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// AttemptDraft - сохранённый, но ещё не проверенный ответ в попытке.
+// Черновики проверяются все сразу при сдаче попытки.
+type AttemptDraft struct {
+	AttemptID  uint           `gorm:"primary_key"`
+	QuestionID uint           `gorm:"primary_key"`
+	Answer     datatypes.JSON `gorm:"type:jsonb"`
+	UpdatedAt  time.Time
+}
+
+type AttemptDraftResponse struct {
+	QuestionID uint        `json:"questionId"`
+	Answer     interface{} `json:"answer"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+}
+
+func (d *AttemptDraft) ToAttemptDraftResponse() AttemptDraftResponse {
+	return AttemptDraftResponse{
+		QuestionID: d.QuestionID,
+		Answer:     utils.ParseJSONInterface(d.Answer),
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
+
+func ToAttemptDraftsResponse(drafts []*AttemptDraft) []AttemptDraftResponse {
+	responses := make([]AttemptDraftResponse, len(drafts))
+	for i, draft := range drafts {
+		responses[i] = draft.ToAttemptDraftResponse()
+	}
+	return responses
+}
+
+// AttemptResume - всё, что нужно, чтобы продолжить попытку после
+// перезагрузки страницы: вопросы, черновики и оставшееся время.
+type AttemptResume struct {
+	Attempt *Attempt
+	Test    *Test
+	Drafts  []*AttemptDraft
+}
+
+type AttemptResumeResponse struct {
+	Attempt AttemptResponse           `json:"attempt"`
+	Test    TestResponseWithQuestions `json:"test"`
+	Drafts  []AttemptDraftResponse    `json:"drafts"`
+	// RemainingSeconds - сколько осталось до конца попытки, нет при попытке без ограничения времени.
+	RemainingSeconds *int64 `json:"remainingSeconds,omitempty"`
+}
+
+func (r *AttemptResume) ToAttemptResumeResponse(now time.Time) AttemptResumeResponse {
+	return AttemptResumeResponse{
+		Attempt:          r.Attempt.ToAttemptResponse(),
+		Test:             r.Test.ToTestResponseWithQuestions(false),
+		Drafts:           ToAttemptDraftsResponse(r.Drafts),
+		RemainingSeconds: r.Attempt.RemainingSeconds(now),
+	}
+}
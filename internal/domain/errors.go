@@ -20,8 +20,50 @@ var (
 	ErrInvalidRole         = errors.New("Данный функционал доступен только преподавателю!")
 	/* course */
 	ErrCourseNotFound = errors.New("Курс не найден")
+	ErrNotEnrolled    = errors.New("Вы не записаны на этот курс")
 	/* test */
-	ErrTestNotFound = errors.New("Тест не найден")
+	ErrTestNotFound    = errors.New("Тест не найден")
+	ErrTestUnavailable = errors.New("Тест недоступен для вашей группы")
+	ErrTestLocked      = errors.New("Тест заблокирован: не выполнены предварительные условия")
+	ErrTestEnded       = errors.New("Тест уже завершён")
+	ErrDeadlinePassed  = errors.New("Срок сдачи теста истёк")
+	ErrTestNameTaken   = errors.New("Тест с таким названием уже существует")
+	ErrTestNotStarted  = errors.New("Попытка прохождения теста не начата")
+	ErrNoAttemptsLeft  = errors.New("Исчерпано количество попыток прохождения теста")
+	ErrAttemptExpired  = errors.New("Время на прохождение теста истекло")
+	ErrAttemptNotFound = errors.New("Попытка не найдена")
+	ErrAnswersHidden   = errors.New("Правильные ответы этого теста пока недоступны")
+	/* adaptive */
+	ErrTestNotAdaptive = errors.New("Тест не адаптивный")
+	/* practice */
+	ErrPracticeUnavailable   = errors.New("Практика по этому тесту недоступна")
+	ErrTestNotEnded          = errors.New("Практику можно включить только после завершения теста")
+	ErrPracticeAnswersHidden = errors.New("Практику нельзя включить: правильные ответы этого теста не показываются")
+	/* test package */
+	ErrUnsupportedPackage = errors.New("Неподдерживаемый формат или версия пакета теста")
+	ErrInvalidTestPackage = errors.New("Пакет теста некорректен")
+	/* test extension */
+	ErrExtensionNotFound  = errors.New("Продление дедлайна не найдено")
+	ErrStudentNotEnrolled = errors.New("Студент не записан на курс теста")
+	/* test status */
+	ErrInvalidStatusTransition = errors.New("Недопустимая смена статуса теста")
+	ErrTestHasNoQuestions      = errors.New("Нельзя запустить тест без вопросов")
+	/* question pool */
+	ErrInvalidPool           = errors.New("У пула должны быть заданы тема или набор вопросов и их количество")
+	ErrPoolTooSmall          = errors.New("В пуле меньше подходящих вопросов, чем нужно выбрать")
+	ErrQuestionNotInAttempt  = errors.New("Этот вопрос не входит в вашу попытку")
+	ErrAnswerAlreadyRevealed = errors.New("Правильный ответ на этот вопрос уже показан, повторная проверка невозможна")
+	/* question order */
+	ErrInvalidQuestionOrder = errors.New("Порядок должен содержать каждый вопрос теста ровно один раз")
+	ErrInvalidQuestionBatch = errors.New("Вопрос нельзя одновременно прикрепить и открепить, а при замене открепление не указывается")
+	/* user test */
+	ErrClientProgressRejected = errors.New("Результат теста считается сервером, передавать progress нельзя")
+	/* prerequisite */
+	ErrPrerequisiteCycle = errors.New("Предварительные условия не могут ссылаться сами на себя по цепочке")
+	/* group */
+	ErrGroupNotFound          = errors.New("Группа не найдена")
+	ErrGroupUserNotEnrolled   = errors.New("В группу можно добавить только студентов, записанных на её курс")
+	ErrGroupTestOutsideCourse = errors.New("Тест не относится к курсу группы")
 	/* question */
 	ErrQuestionNotFound = errors.New("Вопрос не найден")
 )
@@ -1,9 +1,11 @@
 package domain
 
 const (
-	TopicUserAnswers    = "user_answers"
-	TopicUserTest       = "user_test"
-	TopicCreateQuestion = "question_create"
-	TopicEditQuestion   = "question_edit"
-	TopicDeleteQuestion = "question_delete"
+	TopicUserAnswers     = "user_answers"
+	TopicPracticeAnswers = "practice_answers"
+	TopicUserTest        = "user_test"
+	TopicTestEnded       = "test_ended"
+	TopicCreateQuestion  = "question_create"
+	TopicEditQuestion    = "question_edit"
+	TopicDeleteQuestion  = "question_delete"
 )
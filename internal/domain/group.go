@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Group - академическая группа внутри курса. Один курс может читаться
+// нескольким группам с разными дедлайнами по одним и тем же тестам.
+type Group struct {
+	gorm.Model
+	ID        uint         `gorm:"primaryKey;autoIncrement"`
+	CourseID  uint         `gorm:"not null;index"`
+	Name      string       `gorm:"not null"`
+	Users     []*User      `gorm:"many2many:group_users;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Overrides []*GroupTest `gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+type GroupResponse struct {
+	ID        uint                `json:"id"`
+	CourseID  uint                `json:"courseId"`
+	Name      string              `json:"name"`
+	Users     []UserResponse      `json:"users"`
+	Overrides []GroupTestResponse `json:"overrides"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+type GroupTestResponse struct {
+	TestID    uint       `json:"testId"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+	Available *bool      `json:"available,omitempty"`
+}
+
+func (g *Group) ToGroupResponse() GroupResponse {
+	users := make([]UserResponse, len(g.Users))
+	for i, user := range g.Users {
+		users[i] = user.ToUserResponse()
+	}
+
+	overrides := make([]GroupTestResponse, len(g.Overrides))
+	for i, override := range g.Overrides {
+		overrides[i] = override.ToGroupTestResponse()
+	}
+
+	return GroupResponse{
+		ID:        g.ID,
+		CourseID:  g.CourseID,
+		Name:      g.Name,
+		Users:     users,
+		Overrides: overrides,
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+	}
+}
+
+func ToGroupsResponse(groups []*Group) []GroupResponse {
+	responses := make([]GroupResponse, len(groups))
+	for i, group := range groups {
+		responses[i] = group.ToGroupResponse()
+	}
+	return responses
+}
+
+func (gt *GroupTest) ToGroupTestResponse() GroupTestResponse {
+	return GroupTestResponse{
+		TestID:    gt.TestID,
+		Deadline:  gt.Deadline,
+		Available: gt.Available,
+	}
+}
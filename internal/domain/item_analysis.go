@@ -0,0 +1,298 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Пороги для пометки проблемных вопросов по классической теории тестов.
+const (
+	// MinItemResponses - меньше ответов статистика ненадёжна и флаги не ставятся.
+	MinItemResponses  = 10
+	tooHardPValue     = 0.2
+	tooEasyPValue     = 0.9
+	lowDiscrimination = 0.2
+	// discriminationGroupShare - доля лучших и худших попыток для индекса дискриминации.
+	discriminationGroupShare = 0.27
+)
+
+type ItemFlag string
+
+const (
+	FlagTooHard                ItemFlag = "TOO_HARD"
+	FlagTooEasy                ItemFlag = "TOO_EASY"
+	FlagLowDiscrimination      ItemFlag = "LOW_DISCRIMINATION"
+	FlagNegativeDiscrimination ItemFlag = "NEGATIVE_DISCRIMINATION"
+	FlagUnusedDistractor       ItemFlag = "UNUSED_DISTRACTOR"
+)
+
+// ItemResponse - ответ из истории вместе с данными завершённой попытки,
+// в которой он дан.
+type ItemResponse struct {
+	UserAnswer
+	AttemptScore     uint
+	AttemptStartedAt time.Time
+}
+
+// DistractorStats - как часто выбирался вариант ответа.
+type DistractorStats struct {
+	Key     string  `json:"key"`
+	Correct bool    `json:"correct"`
+	Count   int     `json:"count"`
+	Share   float64 `json:"share"`
+}
+
+// QuestionStats - статистика вопроса в тесте TestID или, при TestID = 0,
+// по всем тестам. Считается периодически по истории ответов завершённых попыток.
+type QuestionStats struct {
+	QuestionID     uint                                 `gorm:"primary_key"`
+	TestID         uint                                 `gorm:"primary_key"`
+	Responses      int                                  `gorm:"not null;default:0"`
+	PValue         float64                              `gorm:"not null;default:0"`
+	Discrimination float64                              `gorm:"not null;default:0"`
+	AvgTimeSeconds float64                              `gorm:"not null;default:0"`
+	Distractors    datatypes.JSONSlice[DistractorStats] `gorm:"type:jsonb"`
+	Flags          datatypes.JSONSlice[ItemFlag]        `gorm:"type:jsonb"`
+	ComputedAt     time.Time                            `gorm:"not null;index"`
+}
+
+// TestStatsRun - момент последнего расчёта статистики теста. Пишется и
+// тогда, когда в тесте нет ответов для анализа, чтобы тест не считался
+// устаревшим до новых завершённых попыток.
+type TestStatsRun struct {
+	TestID     uint      `gorm:"primary_key"`
+	ComputedAt time.Time `gorm:"not null"`
+}
+
+type QuestionStatsResponse struct {
+	QuestionID     uint              `json:"questionId"`
+	TestID         uint              `json:"testId,omitempty"`
+	Responses      int               `json:"responses"`
+	PValue         float64           `json:"pValue"`
+	Discrimination float64           `json:"discrimination"`
+	AvgTimeSeconds float64           `json:"avgTimeSeconds"`
+	Distractors    []DistractorStats `json:"distractors,omitempty"`
+	Flags          []ItemFlag        `json:"flags,omitempty" enums:"TOO_HARD,TOO_EASY,LOW_DISCRIMINATION,NEGATIVE_DISCRIMINATION,UNUSED_DISTRACTOR"`
+	ComputedAt     time.Time         `json:"computedAt"`
+}
+
+func (s *QuestionStats) ToQuestionStatsResponse() QuestionStatsResponse {
+	return QuestionStatsResponse{
+		QuestionID:     s.QuestionID,
+		TestID:         s.TestID,
+		Responses:      s.Responses,
+		PValue:         s.PValue,
+		Discrimination: s.Discrimination,
+		AvgTimeSeconds: s.AvgTimeSeconds,
+		Distractors:    s.Distractors,
+		Flags:          s.Flags,
+		ComputedAt:     s.ComputedAt,
+	}
+}
+
+func ToQuestionsStatsResponse(stats []*QuestionStats) []QuestionStatsResponse {
+	response := make([]QuestionStatsResponse, len(stats))
+	for i, s := range stats {
+		response[i] = s.ToQuestionStatsResponse()
+	}
+
+	return response
+}
+
+// itemResult - итоговый ответ попытки на вопрос.
+type itemResult struct {
+	answer       *ItemResponse
+	attemptScore uint
+	timeSpent    time.Duration
+}
+
+// AnalyzeItems считает статистику вопросов по истории ответов. В попытке
+// учитывается последний ответ на вопрос, а время на вопрос - сумма
+// промежутков от предыдущего действия в попытке до каждого ответа на него.
+// responses должны быть упорядочены по времени.
+func AnalyzeItems(testID uint, responses []*ItemResponse, questions map[uint]*Question, now time.Time) []*QuestionStats {
+	type key struct{ attemptID, questionID uint }
+
+	final := make(map[key]*itemResult)
+	previous := make(map[uint]time.Time)
+	for _, response := range responses {
+		prev, ok := previous[response.AttemptID]
+		if !ok {
+			prev = response.AttemptStartedAt
+		}
+		previous[response.AttemptID] = response.CreatedAt
+
+		k := key{response.AttemptID, response.QuestionID}
+		result, ok := final[k]
+		if !ok {
+			result = &itemResult{attemptScore: response.AttemptScore}
+			final[k] = result
+		}
+		result.answer = response
+		if spent := response.CreatedAt.Sub(prev); spent > 0 {
+			result.timeSpent += spent
+		}
+	}
+
+	byQuestion := make(map[uint][]*itemResult)
+	for k, result := range final {
+		byQuestion[k.questionID] = append(byQuestion[k.questionID], result)
+	}
+
+	stats := make([]*QuestionStats, 0, len(byQuestion))
+	for questionID, results := range byQuestion {
+		question, ok := questions[questionID]
+		if !ok {
+			continue
+		}
+		stats = append(stats, analyzeItem(testID, question, results, now))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].QuestionID < stats[j].QuestionID })
+
+	return stats
+}
+
+func analyzeItem(testID uint, question *Question, results []*itemResult, now time.Time) *QuestionStats {
+	stats := &QuestionStats{
+		QuestionID: question.ID,
+		TestID:     testID,
+		Responses:  len(results),
+		ComputedAt: now,
+	}
+
+	var correct int
+	var spent time.Duration
+	for _, result := range results {
+		if result.answer.IsCorrect {
+			correct++
+		}
+		spent += result.timeSpent
+	}
+	stats.PValue = round2(float64(correct) / float64(len(results)))
+	stats.AvgTimeSeconds = round2(spent.Seconds() / float64(len(results)))
+	stats.Discrimination = round2(discrimination(results))
+
+	if question.Type == Single || question.Type == Multiple {
+		stats.Distractors = distractors(question, results)
+	}
+
+	if stats.Responses >= MinItemResponses {
+		stats.Flags = itemFlags(stats)
+	}
+
+	return stats
+}
+
+// discrimination - индекс дискриминации: доля верных ответов в верхней
+// группе попыток по баллу минус доля в нижней.
+func discrimination(results []*itemResult) float64 {
+	sorted := append([]*itemResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].attemptScore > sorted[j].attemptScore })
+
+	size := int(math.Round(float64(len(sorted)) * discriminationGroupShare))
+	if size == 0 {
+		size = 1
+	}
+	if size*2 > len(sorted) {
+		return 0
+	}
+
+	share := func(group []*itemResult) float64 {
+		var correct int
+		for _, result := range group {
+			if result.answer.IsCorrect {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(group))
+	}
+
+	return share(sorted[:size]) - share(sorted[len(sorted)-size:])
+}
+
+func distractors(question *Question, results []*itemResult) []DistractorStats {
+	var variants map[string]interface{}
+	if err := json.Unmarshal(question.Variants, &variants); err != nil {
+		return nil
+	}
+
+	correctKeys := make(map[string]bool)
+	for _, key := range selectedKeys(question.Answer) {
+		correctKeys[key] = true
+	}
+
+	counts := make(map[string]int, len(variants))
+	for _, result := range results {
+		for _, key := range selectedKeys(result.answer.Answer) {
+			counts[key]++
+		}
+	}
+
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	stats := make([]DistractorStats, len(keys))
+	for i, key := range keys {
+		stats[i] = DistractorStats{
+			Key:     key,
+			Correct: correctKeys[key],
+			Count:   counts[key],
+			Share:   round2(float64(counts[key]) / float64(len(results))),
+		}
+	}
+
+	return stats
+}
+
+// selectedKeys разбирает ответ SINGLE (строка) или MULTIPLE (массив строк).
+func selectedKeys(answer datatypes.JSON) []string {
+	var single string
+	if err := json.Unmarshal(answer, &single); err == nil {
+		return []string{single}
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(answer, &multiple); err == nil {
+		return multiple
+	}
+
+	return nil
+}
+
+func itemFlags(stats *QuestionStats) []ItemFlag {
+	var flags []ItemFlag
+
+	switch {
+	case stats.PValue < tooHardPValue:
+		flags = append(flags, FlagTooHard)
+	case stats.PValue > tooEasyPValue:
+		flags = append(flags, FlagTooEasy)
+	}
+
+	switch {
+	case stats.Discrimination < 0:
+		flags = append(flags, FlagNegativeDiscrimination)
+	case stats.Discrimination < lowDiscrimination:
+		flags = append(flags, FlagLowDiscrimination)
+	}
+
+	for _, distractor := range stats.Distractors {
+		if !distractor.Correct && distractor.Count == 0 {
+			flags = append(flags, FlagUnusedDistractor)
+			break
+		}
+	}
+
+	return flags
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
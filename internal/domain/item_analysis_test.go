@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func itemResults(scores []uint, correct []bool) []*itemResult {
+	results := make([]*itemResult, len(scores))
+	for i := range scores {
+		results[i] = &itemResult{
+			answer:       &ItemResponse{UserAnswer: UserAnswer{IsCorrect: correct[i]}},
+			attemptScore: scores[i],
+		}
+	}
+	return results
+}
+
+func TestDiscrimination(t *testing.T) {
+	tests := []struct {
+		name    string
+		scores  []uint
+		correct []bool
+		want    float64
+	}{
+		{"single response", []uint{80}, []bool{true}, 0},
+		{"two responses", []uint{20, 90}, []bool{false, true}, 1},
+		{"three responses use one per group", []uint{90, 50, 10}, []bool{true, false, true}, 0},
+		{"ties keep input order", []uint{50, 50, 50, 50}, []bool{true, true, false, false}, 1},
+		{
+			name:    "27 percent groups of ten",
+			scores:  []uint{100, 90, 80, 70, 60, 50, 40, 30, 20, 10},
+			correct: []bool{true, true, false, false, false, true, true, false, false, true},
+			want:    2.0/3 - 1.0/3,
+		},
+		{"all correct has no variance", []uint{90, 70, 50, 30}, []bool{true, true, true, true}, 0},
+		{"all wrong has no variance", []uint{90, 70, 50, 30}, []bool{false, false, false, false}, 0},
+		{"weak students answer better", []uint{90, 70, 50, 30}, []bool{false, false, true, true}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discrimination(itemResults(tt.scores, tt.correct)); got != tt.want {
+				t.Errorf("discrimination() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistractors(t *testing.T) {
+	variants := map[string]string{"a": "A", "b": "B", "c": "C"}
+
+	tests := []struct {
+		name     string
+		question Question
+		answers  []interface{}
+		want     []DistractorStats
+	}{
+		{
+			name:     "single choice",
+			question: Question{Type: Single, Answer: jsonValue(t, "a"), Variants: jsonValue(t, variants)},
+			answers:  []interface{}{"a", "a", "b", "a"},
+			want: []DistractorStats{
+				{Key: "a", Correct: true, Count: 3, Share: 0.75},
+				{Key: "b", Count: 1, Share: 0.25},
+				{Key: "c", Count: 0, Share: 0},
+			},
+		},
+		{
+			name:     "multiple choice counts every picked variant",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b"}), Variants: jsonValue(t, variants)},
+			answers:  []interface{}{[]string{"a", "b"}, []string{"a", "c"}, []string{"b"}},
+			want: []DistractorStats{
+				{Key: "a", Correct: true, Count: 2, Share: 0.67},
+				{Key: "b", Correct: true, Count: 2, Share: 0.67},
+				{Key: "c", Count: 1, Share: 0.33},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]*itemResult, len(tt.answers))
+			for i, answer := range tt.answers {
+				results[i] = &itemResult{answer: &ItemResponse{UserAnswer: UserAnswer{Answer: jsonValue(t, answer)}}}
+			}
+
+			got := distractors(&tt.question, results)
+			if len(got) != len(tt.want) {
+				t.Fatalf("distractors() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("distractor %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeItemsPValue(t *testing.T) {
+	started := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	response := func(attemptID, questionID uint, correct bool, after time.Duration) *ItemResponse {
+		return &ItemResponse{
+			UserAnswer: UserAnswer{
+				AttemptID:  attemptID,
+				QuestionID: questionID,
+				IsCorrect:  correct,
+				CreatedAt:  started.Add(after),
+			},
+			AttemptStartedAt: started,
+		}
+	}
+
+	questions := map[uint]*Question{1: {ID: 1, Type: Text}}
+	responses := []*ItemResponse{
+		// в попытке учитывается последний ответ
+		response(1, 1, false, 10*time.Second),
+		response(1, 1, true, 30*time.Second),
+		response(2, 1, true, 20*time.Second),
+		response(3, 1, false, 40*time.Second),
+		response(4, 1, false, 10*time.Second),
+		// вопрос не из теста пропускается
+		response(4, 2, true, 20*time.Second),
+	}
+
+	stats := AnalyzeItems(7, responses, questions, started)
+
+	if len(stats) != 1 {
+		t.Fatalf("len(AnalyzeItems) = %d, want 1", len(stats))
+	}
+	got := stats[0]
+	if got.TestID != 7 || got.QuestionID != 1 || got.Responses != 4 {
+		t.Errorf("stats = test %d question %d responses %d, want 7, 1, 4", got.TestID, got.QuestionID, got.Responses)
+	}
+	if got.PValue != 0.5 {
+		t.Errorf("PValue = %v, want 0.5", got.PValue)
+	}
+	if got.AvgTimeSeconds != 25 {
+		t.Errorf("AvgTimeSeconds = %v, want 25", got.AvgTimeSeconds)
+	}
+	if got.Flags != nil {
+		t.Errorf("Flags = %v, want none below %d responses", got.Flags, MinItemResponses)
+	}
+}
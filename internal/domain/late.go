@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// LatePenalty - как снижается результат попытки, сданной после дедлайна
+// в пределах Test.LateHours.
+type LatePenalty string
+
+const (
+	// NoLatePenalty - поздняя сдача только помечается.
+	NoLatePenalty LatePenalty = "NONE"
+	// LinearPenalty - штраф растёт от нуля в момент дедлайна до
+	// LatePenaltyPercent к концу окна поздней сдачи.
+	LinearPenalty LatePenalty = "LINEAR"
+	// SteppedPenalty - LatePenaltyPercent за каждый начатый час опоздания.
+	SteppedPenalty LatePenalty = "STEPPED"
+)
+
+func (p LatePenalty) String() string {
+	return string(p)
+}
+
+// TestExtension - индивидуальный дедлайн студента. Он заменяет дедлайны
+// теста и групп, а окно поздней сдачи и штраф к студенту не применяются.
+type TestExtension struct {
+	TestID    uint      `gorm:"primary_key"`
+	UserID    uint      `gorm:"primary_key"`
+	Deadline  time.Time `gorm:"not null"`
+	GrantedBy uint      `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type TestExtensionResponse struct {
+	TestID    uint      `json:"testId"`
+	UserID    uint      `json:"userId"`
+	Deadline  time.Time `json:"deadline"`
+	GrantedBy uint      `json:"grantedBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (e *TestExtension) ToTestExtensionResponse() TestExtensionResponse {
+	return TestExtensionResponse{
+		TestID:    e.TestID,
+		UserID:    e.UserID,
+		Deadline:  e.Deadline,
+		GrantedBy: e.GrantedBy,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+func ToTestExtensionsResponse(extensions []*TestExtension) []TestExtensionResponse {
+	responses := make([]TestExtensionResponse, len(extensions))
+	for i, extension := range extensions {
+		responses[i] = extension.ToTestExtensionResponse()
+	}
+	return responses
+}
+
+// ApplyExtension подставляет индивидуальный дедлайн студента вместо
+// дедлайна теста и отключает для него позднюю сдачу.
+func (c *Test) ApplyExtension(extension *TestExtension) {
+	if extension == nil || extension.TestID != c.ID {
+		return
+	}
+
+	c.Deadline = extension.Deadline
+	c.LateHours = 0
+	c.Extended = true
+}
+
+// ClosesAt - последний момент, когда ещё принимаются попытки: дедлайн
+// плюс окно поздней сдачи.
+func (c *Test) ClosesAt() time.Time {
+	return c.Deadline.Add(time.Duration(c.LateHours) * time.Hour)
+}
+
+// Lateness сообщает, сдана ли попытка в submittedAt после дедлайна, и
+// штраф в процентах от результата по правилу LatePenalty.
+func (c *Test) Lateness(submittedAt time.Time) (bool, uint) {
+	if !c.HasDeadline() || !submittedAt.After(c.Deadline) {
+		return false, 0
+	}
+
+	late := submittedAt.Sub(c.Deadline)
+	var penalty float64
+	switch c.LatePenalty {
+	case LinearPenalty:
+		penalty = float64(c.LatePenaltyPercent)
+		if window := time.Duration(c.LateHours) * time.Hour; window > 0 && late < window {
+			penalty = penalty * late.Hours() / window.Hours()
+		}
+	case SteppedPenalty:
+		penalty = float64(c.LatePenaltyPercent) * math.Ceil(late.Hours())
+	}
+
+	return true, uint(math.Min(100, math.Round(penalty)))
+}
+
+// ApplyLatePenalty помечает попытку поздней и снижает её результат и
+// баллы на penalty процентов.
+func (a *Attempt) ApplyLatePenalty(penalty uint) {
+	a.Late = true
+	a.LatePenalty = penalty
+	a.Score = uint(math.Round(float64(a.Score) * float64(100-penalty) / 100))
+	a.Points = round2(a.Points * float64(100-penalty) / 100)
+}
+
+// SubmittedAt - момент сдачи попытки, закрытой в now. Брошенная попытка
+// считается сданной в момент окончания её времени.
+func (a *Attempt) SubmittedAt(now time.Time) time.Time {
+	if a.ExpiresAt != nil && a.ExpiresAt.Before(now) {
+		return *a.ExpiresAt
+	}
+	return now
+}
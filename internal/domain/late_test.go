@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTestLateness(t *testing.T) {
+	deadline := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		test        Test
+		submittedAt time.Time
+		wantLate    bool
+		wantPenalty uint
+	}{
+		{
+			name:        "no deadline",
+			test:        Test{LatePenalty: LinearPenalty, LatePenaltyPercent: 50, LateHours: 10},
+			submittedAt: deadline,
+		},
+		{
+			name:        "exactly at deadline",
+			test:        Test{Deadline: deadline, LatePenalty: LinearPenalty, LatePenaltyPercent: 50, LateHours: 10},
+			submittedAt: deadline,
+		},
+		{
+			name:        "no penalty",
+			test:        Test{Deadline: deadline, LatePenalty: NoLatePenalty, LatePenaltyPercent: 50, LateHours: 10},
+			submittedAt: deadline.Add(time.Hour),
+			wantLate:    true,
+		},
+		{
+			name:        "linear in the middle of the window",
+			test:        Test{Deadline: deadline, LatePenalty: LinearPenalty, LatePenaltyPercent: 40, LateHours: 10},
+			submittedAt: deadline.Add(5 * time.Hour),
+			wantLate:    true,
+			wantPenalty: 20,
+		},
+		{
+			name:        "linear after the window is full",
+			test:        Test{Deadline: deadline, LatePenalty: LinearPenalty, LatePenaltyPercent: 40, LateHours: 10},
+			submittedAt: deadline.Add(12 * time.Hour),
+			wantLate:    true,
+			wantPenalty: 40,
+		},
+		{
+			name:        "linear without window is full",
+			test:        Test{Deadline: deadline, LatePenalty: LinearPenalty, LatePenaltyPercent: 40},
+			submittedAt: deadline.Add(time.Minute),
+			wantLate:    true,
+			wantPenalty: 40,
+		},
+		{
+			name:        "stepped counts started hours",
+			test:        Test{Deadline: deadline, LatePenalty: SteppedPenalty, LatePenaltyPercent: 15, LateHours: 24},
+			submittedAt: deadline.Add(time.Hour + time.Minute),
+			wantLate:    true,
+			wantPenalty: 30,
+		},
+		{
+			name:        "stepped is capped at 100",
+			test:        Test{Deadline: deadline, LatePenalty: SteppedPenalty, LatePenaltyPercent: 30, LateHours: 24},
+			submittedAt: deadline.Add(5 * time.Hour),
+			wantLate:    true,
+			wantPenalty: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			late, penalty := tt.test.Lateness(tt.submittedAt)
+			if late != tt.wantLate || penalty != tt.wantPenalty {
+				t.Errorf("Lateness() = (%v, %d), want (%v, %d)", late, penalty, tt.wantLate, tt.wantPenalty)
+			}
+		})
+	}
+}
+
+func TestAttemptApplyLatePenalty(t *testing.T) {
+	attempt := &Attempt{Score: 85, Points: 8.5}
+	attempt.ApplyLatePenalty(20)
+
+	if !attempt.Late || attempt.LatePenalty != 20 || attempt.Score != 68 || attempt.Points != 6.8 {
+		t.Errorf("ApplyLatePenalty(20) = %+v", attempt)
+	}
+}
+
+func TestTestApplyExtension(t *testing.T) {
+	deadline := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	extended := deadline.Add(48 * time.Hour)
+
+	test := &Test{ID: 1, Deadline: deadline, LateHours: 24}
+	test.ApplyExtension(&TestExtension{TestID: 2, Deadline: extended})
+	if test.Extended || !test.Deadline.Equal(deadline) {
+		t.Fatalf("extension of another test applied: %+v", test)
+	}
+
+	test.ApplyExtension(&TestExtension{TestID: 1, Deadline: extended})
+	if !test.Extended || !test.Deadline.Equal(extended) || test.LateHours != 0 {
+		t.Errorf("ApplyExtension() = deadline %v, late hours %d, extended %v", test.Deadline, test.LateHours, test.Extended)
+	}
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type UserCourse struct {
 	UserID   uint `gorm:"primary_key"`
 	CourseID uint `gorm:"primary_key"`
@@ -10,16 +12,48 @@ type CourseTest struct {
 	TestID   uint `gorm:"primary_key"`
 }
 
+// TestQuestion - вопрос в тесте. Position задаёт порядок вопросов,
+// Points - сколько баллов вопрос стоит в этом тесте.
 type TestQuestion struct {
-	TestID     uint `gorm:"primary_key"`
-	QuestionID uint `gorm:"primary_key"`
+	TestID     uint    `gorm:"primary_key"`
+	QuestionID uint    `gorm:"primary_key"`
+	Position   uint    `gorm:"not null;default:0"`
+	Points     float64 `gorm:"not null;default:1"`
 }
 
 type UserTests struct {
 	TestID   uint `gorm:"primary_key"`
 	UserID   uint `gorm:"primary_key"`
 	Progress uint
+	Attempt  uint           `gorm:"not null;default:0"` // номер последней попытки
 	Status   UserTestStatus `gorm:"type:varchar(20);not null;status IN ('IN_PROGRESS', 'ENDED', 'REC_NEW');default:'IN_PROGRESS'"`
+	Attempts []*Attempt     `gorm:"-"`
+}
+
+// UserTestAnswer - лист ответов текущей попытки: первый проверенный ответ
+// студента на каждый вопрос теста. По нему сервер сам считает результат
+// попытки.
+type UserTestAnswer struct {
+	TestID     uint `gorm:"primary_key"`
+	UserID     uint `gorm:"primary_key"`
+	QuestionID uint `gorm:"primary_key"`
+	IsCorrect  bool `gorm:"not null;default:false"`
+	// Score и MaxScore - оценка ответа с учётом веса вопроса.
+	Score     float64 `gorm:"not null;default:0"`
+	MaxScore  float64 `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// Credit - доля баллов за ответ. Для ответов, сохранённых до появления
+// баллов, берётся IsCorrect.
+func (a *UserTestAnswer) Credit() float64 {
+	if a.MaxScore > 0 {
+		return a.Score / a.MaxScore
+	}
+	if a.IsCorrect {
+		return 1
+	}
+	return 0
 }
 
 type UserTestStatus string
@@ -30,18 +64,49 @@ const (
 	Completed  UserTestStatus = "COMPLETED"
 )
 
+// IsFinished - студент завершил тест и его результат можно учитывать.
+func (ut *UserTests) IsFinished() bool {
+	return ut.Status != "" && ut.Status != InProgress && ut.Status != New
+}
+
+// HasCompletedAttempt - у студента есть хотя бы одна завершённая попытка, и
+// Progress хранит итог по ним. Во время пересдачи статус снова IN_PROGRESS,
+// но открытой может быть только одна попытка, поэтому все предыдущие
+// завершены.
+func (ut *UserTests) HasCompletedAttempt() bool {
+	return ut.IsFinished() || (ut.Status == InProgress && ut.Attempt > 1)
+}
+
 func (ut UserTestStatus) String() string {
 	return string(ut)
 }
 
 type UserTestResponse struct {
-	Progress uint   `json:"progress"`
-	Status   string `json:"status"`
+	Progress uint              `json:"progress"`
+	Attempt  uint              `json:"attempt"`
+	Status   string            `json:"status"`
+	Attempts []AttemptResponse `json:"attempts,omitempty"`
 }
 
 func (ut *UserTests) ToUserTestResponse() UserTestResponse {
 	return UserTestResponse{
 		Progress: ut.Progress,
+		Attempt:  ut.Attempt,
 		Status:   ut.Status.String(),
+		Attempts: ToAttemptsResponse(ut.Attempts),
 	}
 }
+
+type GroupUser struct {
+	GroupID uint `gorm:"primary_key"`
+	UserID  uint `gorm:"primary_key"`
+}
+
+// GroupTest - переопределение дедлайна и доступности теста для группы.
+// Пустые поля означают, что для группы действует значение самого теста.
+type GroupTest struct {
+	GroupID   uint `gorm:"primary_key"`
+	TestID    uint `gorm:"primary_key"`
+	Deadline  *time.Time
+	Available *bool
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"math/rand"
+
+	"gorm.io/datatypes"
+)
+
+// QuestionPool - правило теста «взять Count случайных вопросов»: из вопросов
+// с темой Tag или из набора QuestionIDs, при заданной Difficulty - только
+// этой сложности. Вопросы тянутся при начале попытки и фиксируются за ней.
+type QuestionPool struct {
+	ID          uint                      `gorm:"primaryKey;autoIncrement"`
+	TestID      uint                      `gorm:"not null;index"`
+	Tag         string                    `gorm:"not null;default:''"`
+	QuestionIDs datatypes.JSONSlice[uint] `gorm:"type:jsonb"`
+	Count       uint                      `gorm:"not null"`
+	Difficulty  *uint
+}
+
+// AttemptQuestion - вопрос, выданный студенту в попытке теста с пулами.
+type AttemptQuestion struct {
+	AttemptID  uint `gorm:"primary_key"`
+	QuestionID uint `gorm:"primary_key"`
+	Position   uint `gorm:"not null"`
+}
+
+type QuestionPoolResponse struct {
+	ID          uint   `json:"id"`
+	Tag         string `json:"tag,omitempty"`
+	QuestionIDs []uint `json:"questionIds,omitempty"`
+	Count       uint   `json:"count"`
+	Difficulty  *uint  `json:"difficulty,omitempty"`
+}
+
+func (p *QuestionPool) ToQuestionPoolResponse() QuestionPoolResponse {
+	return QuestionPoolResponse{
+		ID:          p.ID,
+		Tag:         p.Tag,
+		QuestionIDs: p.QuestionIDs,
+		Count:       p.Count,
+		Difficulty:  p.Difficulty,
+	}
+}
+
+func ToQuestionPoolsResponse(pools []*QuestionPool) []QuestionPoolResponse {
+	response := make([]QuestionPoolResponse, len(pools))
+	for i, pool := range pools {
+		response[i] = pool.ToQuestionPoolResponse()
+	}
+
+	return response
+}
+
+// Copy возвращает то же правило без привязки к тесту.
+func (p *QuestionPool) Copy() *QuestionPool {
+	return &QuestionPool{
+		Tag:         p.Tag,
+		QuestionIDs: p.QuestionIDs,
+		Count:       p.Count,
+		Difficulty:  p.Difficulty,
+	}
+}
+
+// Validate проверяет, что у правила задан источник вопросов и их число.
+func (p *QuestionPool) Validate() error {
+	if p.Count == 0 || (p.Tag == "" && len(p.QuestionIDs) == 0) {
+		return ErrInvalidPool
+	}
+
+	return nil
+}
+
+// DrawQuestions составляет список вопросов попытки: сначала прикреплённые
+// к тесту вопросы, затем случайные вопросы каждого пула. candidates - ID
+// подходящих вопросов по ID пула. Вопрос не попадает в попытку дважды;
+// если в пуле не хватает вопросов, берутся все оставшиеся.
+func (c *Test) DrawQuestions(candidates map[uint][]uint, rnd *rand.Rand) []uint {
+	drawn := make([]uint, 0, len(c.Questions))
+	seen := make(map[uint]bool)
+
+	for _, question := range c.Questions {
+		drawn = append(drawn, question.ID)
+		seen[question.ID] = true
+	}
+
+	for _, pool := range c.Pools {
+		available := make([]uint, 0, len(candidates[pool.ID]))
+		for _, id := range candidates[pool.ID] {
+			if !seen[id] {
+				available = append(available, id)
+			}
+		}
+
+		rnd.Shuffle(len(available), func(i, j int) {
+			available[i], available[j] = available[j], available[i]
+		})
+		if uint(len(available)) > pool.Count {
+			available = available[:pool.Count]
+		}
+
+		for _, id := range available {
+			drawn = append(drawn, id)
+			seen[id] = true
+		}
+	}
+
+	return drawn
+}
+
+// UsesPools сообщает, собирается ли список вопросов теста из пулов.
+func (c *Test) UsesPools() bool {
+	return len(c.Pools) > 0
+}
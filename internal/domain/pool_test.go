@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDrawQuestions(t *testing.T) {
+	test := &Test{
+		Questions: []*Question{{ID: 1}, {ID: 2}},
+		Pools:     []*QuestionPool{{ID: 10, Count: 2}, {ID: 20, Count: 2}},
+	}
+
+	tests := []struct {
+		name       string
+		candidates map[uint][]uint
+		wantLen    int
+	}{
+		{"overlapping pools", map[uint][]uint{10: {2, 3, 4}, 20: {3, 4, 5}}, 5},
+		{"pool smaller than count", map[uint][]uint{10: {3}, 20: {}}, 3},
+		{"pool of attached questions only", map[uint][]uint{10: {1, 2}, 20: {2, 5, 6}}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drawn := test.DrawQuestions(tt.candidates, rand.New(rand.NewSource(1)))
+
+			if len(drawn) != tt.wantLen {
+				t.Fatalf("DrawQuestions() = %v, want %d questions", drawn, tt.wantLen)
+			}
+			if drawn[0] != 1 || drawn[1] != 2 {
+				t.Errorf("DrawQuestions() = %v, want attached questions first", drawn)
+			}
+
+			seen := make(map[uint]bool, len(drawn))
+			for _, id := range drawn {
+				if seen[id] {
+					t.Errorf("DrawQuestions() = %v, question %d drawn twice", drawn, id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestDrawQuestionsIsDeterministicPerSeed(t *testing.T) {
+	test := &Test{Pools: []*QuestionPool{{ID: 10, Count: 3}}}
+	candidates := map[uint][]uint{10: {1, 2, 3, 4, 5, 6, 7, 8, 9, 10}}
+
+	first := test.DrawQuestions(candidates, rand.New(rand.NewSource(42)))
+	again := test.DrawQuestions(candidates, rand.New(rand.NewSource(42)))
+	other := test.DrawQuestions(candidates, rand.New(rand.NewSource(43)))
+
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("DrawQuestions() with the same seed = %v, then %v", first, again)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Errorf("DrawQuestions() with another seed = %v, want it to differ", other)
+	}
+	if candidates[10][0] != 1 || candidates[10][9] != 10 {
+		t.Errorf("DrawQuestions() reordered candidates: %v", candidates[10])
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import "fmt"
+
+// TestPrerequisite - тест TestID открывается только после того, как
+// студент завершил тест RequiredTestID с результатом не ниже MinProgress.
+type TestPrerequisite struct {
+	TestID         uint  `gorm:"primary_key"`
+	RequiredTestID uint  `gorm:"primary_key"`
+	MinProgress    uint  `gorm:"not null;default:0"`
+	RequiredTest   *Test `gorm:"foreignKey:RequiredTestID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// CoursePrerequisite - тесты курса CourseID открываются только после того,
+// как средний результат студента по тестам курса RequiredCourseID
+// достиг MinProgress.
+type CoursePrerequisite struct {
+	CourseID         uint    `gorm:"primary_key"`
+	RequiredCourseID uint    `gorm:"primary_key"`
+	MinProgress      uint    `gorm:"not null;default:0"`
+	RequiredCourse   *Course `gorm:"foreignKey:RequiredCourseID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+type PrerequisiteType string
+
+const (
+	PrerequisiteTest   PrerequisiteType = "TEST"
+	PrerequisiteCourse PrerequisiteType = "COURSE"
+)
+
+func (p PrerequisiteType) String() string {
+	return string(p)
+}
+
+type PrerequisiteResponse struct {
+	Type        string `json:"type" enums:"TEST,COURSE" example:"TEST"`
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	MinProgress uint   `json:"minProgress"`
+	Progress    uint   `json:"progress"`
+}
+
+func (p PrerequisiteResponse) String() string {
+	return fmt.Sprintf("«%s» (нужно %d%%, сейчас %d%%)", p.Name, p.MinProgress, p.Progress)
+}
+
+func (tp *TestPrerequisite) ToPrerequisiteResponse(progress uint) PrerequisiteResponse {
+	response := PrerequisiteResponse{
+		Type:        PrerequisiteTest.String(),
+		ID:          tp.RequiredTestID,
+		MinProgress: tp.MinProgress,
+		Progress:    progress,
+	}
+	if tp.RequiredTest != nil {
+		response.Name = tp.RequiredTest.Name
+	}
+	return response
+}
+
+func (cp *CoursePrerequisite) ToPrerequisiteResponse(progress uint) PrerequisiteResponse {
+	response := PrerequisiteResponse{
+		Type:        PrerequisiteCourse.String(),
+		ID:          cp.RequiredCourseID,
+		MinProgress: cp.MinProgress,
+		Progress:    progress,
+	}
+	if cp.RequiredCourse != nil {
+		response.Name = cp.RequiredCourse.Name
+	}
+	return response
+}
+
+func ToTestPrerequisitesResponse(prerequisites []*TestPrerequisite) []PrerequisiteResponse {
+	responses := make([]PrerequisiteResponse, len(prerequisites))
+	for i, prerequisite := range prerequisites {
+		responses[i] = prerequisite.ToPrerequisiteResponse(0)
+	}
+	return responses
+}
+
+func ToCoursePrerequisitesResponse(prerequisites []*CoursePrerequisite) []PrerequisiteResponse {
+	responses := make([]PrerequisiteResponse, len(prerequisites))
+	for i, prerequisite := range prerequisites {
+		responses[i] = prerequisite.ToPrerequisiteResponse(0)
+	}
+	return responses
+}
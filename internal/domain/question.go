@@ -3,6 +3,8 @@ package domain
 import (
 	"diprec_api/internal/pkg/utils"
 	"encoding/json"
+	"math/rand"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +20,16 @@ type Question struct {
 	Variants datatypes.JSON `gorm:"type:jsonb"`
 	Answer   datatypes.JSON `gorm:"type:jsonb"`
 	Tests    []Test         `gorm:"many2many:test_questions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// Tag и Difficulty (1-5, 0 - не задана) - по ним вопросы отбираются
+	// в пулы тестов.
+	Tag        string `gorm:"not null;default:'';index"`
+	Difficulty uint   `gorm:"not null;default:0"`
+	// PartialCredit - правило частичного зачёта для MULTIPLE.
+	PartialCredit PartialCredit `gorm:"type:varchar(20);not null;partial_credit IN ('ALL_OR_NOTHING', 'PROPORTIONAL', 'PENALTY');default:'ALL_OR_NOTHING'"`
+	// Points - вес вопроса в загруженном тесте, задаётся связью test_questions.
+	Points float64 `gorm:"-"`
+	// VariantOrder - порядок показа ключей Variants, если он перемешан.
+	VariantOrder []string `gorm:"-"`
 }
 
 type Type string
@@ -38,26 +50,25 @@ type QuestionResponse struct {
 	Title    string                 `json:"title"`
 	Type     string                 `json:"type" enums:"SINGLE,MULTIPLE,TEXT,NUMBER" example:"SINGLE"`
 	Variants map[string]interface{} `json:"variants"`
-	Answer   interface{}            `json:"answer"`
+	// VariantOrder - порядок, в котором показывать варианты. В JSON-объекте
+	// variants порядок ключей не сохраняется.
+	VariantOrder []string    `json:"variantOrder,omitempty"`
+	Answer       interface{} `json:"answer"`
+	Tag          string      `json:"tag,omitempty"`
+	Difficulty   uint        `json:"difficulty,omitempty"`
+	// PartialCredit - правило частичного зачёта, только для MULTIPLE.
+	PartialCredit string  `json:"partialCredit,omitempty" enums:"ALL_OR_NOTHING,PROPORTIONAL,PENALTY"`
+	Points        float64 `json:"points,omitempty"`
 }
 
 type QuestionAnswer struct {
 	IsCorrect bool        `json:"isCorrect"`
+	Score     float64     `json:"score"`
+	MaxScore  float64     `json:"maxScore"`
 	Message   string      `json:"message"`
 	Answer    interface{} `json:"answer"`
 }
 
-type UserAnswer struct {
-	QuestionID uint        `json:"question_id"`
-	Title      string      `json:"question_title"`
-	Type       string      `json:"question_type"`
-	Variants   interface{} `json:"question_variants"`
-	Answer     interface{} `json:"question_answer"`
-	UserID     uint        `json:"user_id"`
-	IsCorrect  bool        `json:"is_correct"`
-	Timestamp  time.Time   `json:"timestamp"`
-}
-
 type UserAnswerCheck struct {
 	QuestionID uint        `json:"question_id"`
 	CourseID   uint        `json:"course_id"`
@@ -67,7 +78,11 @@ type UserAnswerCheck struct {
 	Answer     interface{} `json:"question_answer"`
 	UserID     uint        `json:"user_id"`
 	IsCorrect  bool        `json:"is_correct"`
+	Score      float64     `json:"score"`
+	MaxScore   float64     `json:"max_score"`
 	Timestamp  time.Time   `json:"timestamp"`
+	// Practice - ответ дан в режиме практики и на результаты не влияет.
+	Practice bool `json:"practice,omitempty"`
 }
 
 func (q *Question) CheckAnswer(userAnswer interface{}) bool {
@@ -104,22 +119,66 @@ func (q *Question) CheckAnswer(userAnswer interface{}) bool {
 	return false
 }
 
+// Copy возвращает новый вопрос с тем же содержимым.
+func (c *Question) Copy() *Question {
+	return &Question{
+		Title:         c.Title,
+		Type:          c.Type,
+		Variants:      c.Variants,
+		Answer:        c.Answer,
+		Tag:           c.Tag,
+		Difficulty:    c.Difficulty,
+		PartialCredit: c.PartialCredit,
+	}
+}
+
+// ShuffleVariants задаёт случайный порядок показа вариантов ответа.
+func (c *Question) ShuffleVariants(rnd *rand.Rand) {
+	order := c.variantKeys()
+	rnd.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+	c.VariantOrder = order
+}
+
+func (c *Question) variantKeys() []string {
+	variants := utils.ParseJSONToMap(c.Variants)
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (c *Question) ToQuestionResponse(isTeacher bool) QuestionResponse {
 	if isTeacher {
-		return QuestionResponse{
-			ID:       c.ID,
-			Title:    c.Title,
-			Type:     c.Type.String(),
-			Variants: utils.ParseJSONToMap(c.Variants),
-			Answer:   utils.ParseJSONInterface(c.Answer),
+		response := QuestionResponse{
+			ID:           c.ID,
+			Title:        c.Title,
+			Type:         c.Type.String(),
+			Variants:     utils.ParseJSONToMap(c.Variants),
+			VariantOrder: c.VariantOrder,
+			Answer:       utils.ParseJSONInterface(c.Answer),
+			Tag:          c.Tag,
+			Difficulty:   c.Difficulty,
+			Points:       c.Points,
+		}
+		if c.Type == Multiple {
+			response.PartialCredit = c.PartialCredit.String()
 		}
+		return response
 	}
 
 	return QuestionResponse{
-		ID:       c.ID,
-		Title:    c.Title,
-		Type:     c.Type.String(),
-		Variants: utils.ParseJSONToMap(c.Variants),
+		ID:           c.ID,
+		Title:        c.Title,
+		Type:         c.Type.String(),
+		Variants:     utils.ParseJSONToMap(c.Variants),
+		VariantOrder: c.VariantOrder,
+		Tag:          c.Tag,
+		Difficulty:   c.Difficulty,
+		Points:       c.Points,
 	}
 }
 
@@ -0,0 +1,149 @@
+package domain
+
+import "fmt"
+
+// InsertQuestion возвращает порядок вопросов order со вставленным questionID.
+// При index == nil вопрос добавляется в конец, индекс за пределами списка
+// тоже означает конец. Уже прикреплённый вопрос переносится на позицию index
+// или, если она не задана, остаётся на месте.
+func InsertQuestion(order []uint, questionID uint, index *int) []uint {
+	result := make([]uint, 0, len(order)+1)
+	for _, id := range order {
+		if id == questionID {
+			if index == nil {
+				return order
+			}
+			continue
+		}
+		result = append(result, id)
+	}
+
+	position := len(result)
+	if index != nil && *index >= 0 && *index < position {
+		position = *index
+	}
+
+	result = append(result, 0)
+	copy(result[position+1:], result[position:])
+	result[position] = questionID
+
+	return result
+}
+
+// ValidateQuestionOrder проверяет, что order - перестановка вопросов current.
+func ValidateQuestionOrder(current, order []uint) error {
+	if len(current) != len(order) {
+		return ErrInvalidQuestionOrder
+	}
+
+	attached := make(map[uint]bool, len(current))
+	for _, id := range current {
+		attached[id] = true
+	}
+	for _, id := range order {
+		if !attached[id] {
+			return ErrInvalidQuestionOrder
+		}
+		delete(attached, id)
+	}
+
+	return nil
+}
+
+// QuestionBatch - изменение набора вопросов теста одним запросом.
+type QuestionBatch struct {
+	// Replace - вопросы теста полностью заменяются списком Attach.
+	Replace bool
+	// Attach - вопросы для прикрепления по порядку. Положительный Points
+	// задаёт вес вопроса.
+	Attach []*TestQuestion
+	Detach []uint
+}
+
+// Validate не допускает открепления при замене и одного вопроса сразу в
+// Attach и Detach.
+func (b *QuestionBatch) Validate() error {
+	if b.Replace && len(b.Detach) > 0 {
+		return ErrInvalidQuestionBatch
+	}
+
+	detach := make(map[uint]bool, len(b.Detach))
+	for _, id := range b.Detach {
+		detach[id] = true
+	}
+	for _, link := range b.Attach {
+		if detach[link.QuestionID] {
+			return fmt.Errorf("%w: %d", ErrInvalidQuestionBatch, link.QuestionID)
+		}
+	}
+
+	return nil
+}
+
+// AttachIDs - ID прикрепляемых вопросов без повторов.
+func (b *QuestionBatch) AttachIDs() []uint {
+	seen := make(map[uint]bool, len(b.Attach))
+	ids := make([]uint, 0, len(b.Attach))
+	for _, link := range b.Attach {
+		if !seen[link.QuestionID] {
+			seen[link.QuestionID] = true
+			ids = append(ids, link.QuestionID)
+		}
+	}
+	return ids
+}
+
+// UniqueIDs возвращает ids без повторов в порядке первого появления.
+func UniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Apply возвращает новый порядок вопросов и их веса. Открепляемые вопросы
+// убираются, новые добавляются в конец в порядке Attach, уже прикреплённые
+// остаются на месте. При Replace порядок задаёт Attach.
+func (b *QuestionBatch) Apply(order []uint, weights map[uint]float64) ([]uint, map[uint]float64) {
+	result := make([]uint, 0, len(order)+len(b.Attach))
+	if !b.Replace {
+		detach := make(map[uint]bool, len(b.Detach))
+		for _, id := range b.Detach {
+			detach[id] = true
+		}
+		for _, id := range order {
+			if !detach[id] {
+				result = append(result, id)
+			}
+		}
+	}
+
+	present := make(map[uint]bool, len(result))
+	for _, id := range result {
+		present[id] = true
+	}
+
+	newWeights := make(map[uint]float64, len(result)+len(b.Attach))
+	for _, id := range result {
+		newWeights[id] = weights[id]
+	}
+	for _, link := range b.Attach {
+		if !present[link.QuestionID] {
+			present[link.QuestionID] = true
+			result = append(result, link.QuestionID)
+			if weight, ok := weights[link.QuestionID]; ok {
+				newWeights[link.QuestionID] = weight
+			}
+		}
+		if link.Points > 0 {
+			newWeights[link.QuestionID] = link.Points
+		}
+	}
+
+	return result, newWeights
+}
@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestInsertQuestion(t *testing.T) {
+	at := func(i int) *int { return &i }
+
+	tests := []struct {
+		name       string
+		order      []uint
+		questionID uint
+		index      *int
+		want       []uint
+	}{
+		{"append", []uint{1, 2}, 3, nil, []uint{1, 2, 3}},
+		{"insert at start", []uint{1, 2}, 3, at(0), []uint{3, 1, 2}},
+		{"index past the end appends", []uint{1, 2}, 3, at(10), []uint{1, 2, 3}},
+		{"attached stays in place", []uint{1, 2, 3}, 2, nil, []uint{1, 2, 3}},
+		{"attached moves", []uint{1, 2, 3}, 3, at(0), []uint{3, 1, 2}},
+		{"into empty", nil, 7, at(0), []uint{7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InsertQuestion(tt.order, tt.questionID, tt.index); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertQuestion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateQuestionOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		current []uint
+		order   []uint
+		wantErr bool
+	}{
+		{"permutation", []uint{1, 2, 3}, []uint{3, 1, 2}, false},
+		{"missing question", []uint{1, 2, 3}, []uint{1, 2}, true},
+		{"unknown question", []uint{1, 2}, []uint{1, 5}, true},
+		{"duplicate question", []uint{1, 2}, []uint{1, 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQuestionOrder(tt.current, tt.order)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQuestionOrder() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestionBatchValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		batch   QuestionBatch
+		wantErr bool
+	}{
+		{"attach and detach", QuestionBatch{Attach: []*TestQuestion{{QuestionID: 1}}, Detach: []uint{2}}, false},
+		{"replace", QuestionBatch{Replace: true, Attach: []*TestQuestion{{QuestionID: 1}}}, false},
+		{"replace with detach", QuestionBatch{Replace: true, Detach: []uint{2}}, true},
+		{"same question in attach and detach", QuestionBatch{Attach: []*TestQuestion{{QuestionID: 1}}, Detach: []uint{1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.batch.Validate()
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidQuestionBatch)) {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestionBatchApply(t *testing.T) {
+	order := []uint{1, 2, 3}
+	weights := map[uint]float64{1: 1, 2: 2, 3: 3}
+
+	tests := []struct {
+		name        string
+		batch       QuestionBatch
+		wantOrder   []uint
+		wantWeights map[uint]float64
+	}{
+		{
+			name:        "detach keeps order of the rest",
+			batch:       QuestionBatch{Detach: []uint{2, 9}},
+			wantOrder:   []uint{1, 3},
+			wantWeights: map[uint]float64{1: 1, 3: 3},
+		},
+		{
+			name:        "attach appends new and reweights attached",
+			batch:       QuestionBatch{Attach: []*TestQuestion{{QuestionID: 4, Points: 5}, {QuestionID: 1, Points: 2}, {QuestionID: 5}}},
+			wantOrder:   []uint{1, 2, 3, 4, 5},
+			wantWeights: map[uint]float64{1: 2, 2: 2, 3: 3, 4: 5},
+		},
+		{
+			name:        "duplicate attach is added once",
+			batch:       QuestionBatch{Attach: []*TestQuestion{{QuestionID: 4}, {QuestionID: 4, Points: 3}}},
+			wantOrder:   []uint{1, 2, 3, 4},
+			wantWeights: map[uint]float64{1: 1, 2: 2, 3: 3, 4: 3},
+		},
+		{
+			name:        "replace keeps weights of questions that stay",
+			batch:       QuestionBatch{Replace: true, Attach: []*TestQuestion{{QuestionID: 3}, {QuestionID: 6}}},
+			wantOrder:   []uint{3, 6},
+			wantWeights: map[uint]float64{3: 3},
+		},
+		{
+			name:        "replace with nothing",
+			batch:       QuestionBatch{Replace: true},
+			wantOrder:   []uint{},
+			wantWeights: map[uint]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOrder, gotWeights := tt.batch.Apply(order, weights)
+			if !reflect.DeepEqual(gotOrder, tt.wantOrder) {
+				t.Errorf("Apply() order = %v, want %v", gotOrder, tt.wantOrder)
+			}
+			if !reflect.DeepEqual(gotWeights, tt.wantWeights) {
+				t.Errorf("Apply() weights = %v, want %v", gotWeights, tt.wantWeights)
+			}
+		})
+	}
+}
+
+func shuffleTest(t *testing.T, attemptID uint) *Test {
+	t.Helper()
+
+	enabled := true
+	variants := map[string]string{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}
+	test := &Test{
+		ID:               1,
+		ShuffleQuestions: &enabled,
+		ShuffleVariants:  &enabled,
+		UserTests:        UserTests{Attempts: []*Attempt{{ID: attemptID, Status: InProgress}}},
+	}
+	for id := uint(1); id <= 8; id++ {
+		test.Questions = append(test.Questions, &Question{ID: id, Type: Single, Answer: jsonValue(t, "a"), Variants: jsonValue(t, variants)})
+	}
+	return test
+}
+
+// shuffledOrder - порядок вопросов и вариантов первого из них после
+// перемешивания для студента userID.
+func shuffledOrder(test *Test, userID uint) ([]uint, []string) {
+	test.ShuffleFor(userID)
+
+	ids := make([]uint, len(test.Questions))
+	for i, question := range test.Questions {
+		ids[i] = question.ID
+	}
+	return ids, test.Questions[0].VariantOrder
+}
+
+func TestShuffleForIsStable(t *testing.T) {
+	firstIDs, firstVariants := shuffledOrder(shuffleTest(t, 5), 7)
+	againIDs, againVariants := shuffledOrder(shuffleTest(t, 5), 7)
+
+	if !reflect.DeepEqual(firstIDs, againIDs) || !reflect.DeepEqual(firstVariants, againVariants) {
+		t.Errorf("ShuffleFor() is not stable: %v %v, then %v %v", firstIDs, firstVariants, againIDs, againVariants)
+	}
+}
+
+func TestShuffleForChanges(t *testing.T) {
+	baseIDs, _ := shuffledOrder(shuffleTest(t, 5), 7)
+
+	tests := []struct {
+		name      string
+		attemptID uint
+		userID    uint
+	}{
+		{"next attempt", 6, 7},
+		{"other student", 5, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ids, _ := shuffledOrder(shuffleTest(t, tt.attemptID), tt.userID); reflect.DeepEqual(ids, baseIDs) {
+				t.Errorf("ShuffleFor() order = %v, want it to differ from %v", ids, baseIDs)
+			}
+		})
+	}
+}
+
+func TestShuffleForKeepsAnswerMapping(t *testing.T) {
+	test := shuffleTest(t, 5)
+	test.ShuffleFor(7)
+
+	for _, question := range test.Questions {
+		order := append([]string(nil), question.VariantOrder...)
+		sort.Strings(order)
+		if !reflect.DeepEqual(order, []string{"a", "b", "c", "d", "e"}) {
+			t.Errorf("question %d VariantOrder = %v, want a permutation of the variant keys", question.ID, question.VariantOrder)
+		}
+		if score := question.Grade("a"); !score.Correct() {
+			t.Errorf("question %d: correct key graded as %+v after shuffle", question.ID, score)
+		}
+	}
+}
+
+func TestShuffleForDisabled(t *testing.T) {
+	test := shuffleTest(t, 5)
+	test.ShuffleQuestions, test.ShuffleVariants = nil, nil
+
+	ids, variants := shuffledOrder(test, 7)
+	if !reflect.DeepEqual(ids, []uint{1, 2, 3, 4, 5, 6, 7, 8}) || variants != nil {
+		t.Errorf("ShuffleFor() with shuffling off = %v %v, want original order", ids, variants)
+	}
+}
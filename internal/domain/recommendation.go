@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecommendedTest - тест, созданный рекомендательной системой для одного
+// студента. Повторная рекомендация с тем же ключом (UserID, CourseID,
+// RecommendationID) возвращает уже созданный тест. После ExpiresAt тест
+// скрывается от студента и вместе с завершёнными удаляется фоновой задачей.
+type RecommendedTest struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement"`
+	UserID           uint      `gorm:"not null;uniqueIndex:idx_recommended_tests_key"`
+	CourseID         uint      `gorm:"not null;uniqueIndex:idx_recommended_tests_key"`
+	RecommendationID string    `gorm:"not null;uniqueIndex:idx_recommended_tests_key"`
+	TestID           uint      `gorm:"not null;default:0;index"`
+	ExpiresAt        time.Time `gorm:"not null;index"`
+	CreatedAt        time.Time
+}
+
+// TestName - имя теста рекомендации. Имена тестов уникальны, поэтому к
+// имени от рекомендательной системы добавляется номер рекомендации.
+func (r *RecommendedTest) TestName(name string) string {
+	return fmt.Sprintf("%s #%d", name, r.ID)
+}
+
+// VisibleTo сообщает, виден ли тест рекомендации студенту userID в момент now.
+func (r *RecommendedTest) VisibleTo(userID uint, now time.Time) bool {
+	return r.UserID == userID && now.Before(r.ExpiresAt)
+}
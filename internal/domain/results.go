@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// NotStarted - статус в сводке результатов для студента, который ещё не
+// приступал к тесту. В user_tests он не хранится.
+const NotStarted UserTestStatus = "NOT_STARTED"
+
+// histogramBucketWidth - ширина столбца гистограммы результатов в процентах.
+const histogramBucketWidth = 10
+
+// TestResults - результаты теста по всем записанным на курс студентам.
+type TestResults struct {
+	TestID    uint
+	Students  []*User
+	UserTests []*UserTests
+	Attempts  []*Attempt
+}
+
+type StudentResultResponse struct {
+	UserID       uint       `json:"userId"`
+	Username     string     `json:"username"`
+	FirstName    string     `json:"firstName"`
+	LastName     string     `json:"lastName"`
+	Status       string     `json:"status" enums:"NOT_STARTED,IN_PROGRESS,COMPLETED" example:"COMPLETED"`
+	Score        *uint      `json:"score,omitempty"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+	AttemptCount int        `json:"attemptCount"`
+	// Late - хотя бы одна попытка сдана после дедлайна.
+	Late bool `json:"late"`
+}
+
+type HistogramBucket struct {
+	From  uint `json:"from"`
+	To    uint `json:"to"`
+	Count int  `json:"count"`
+}
+
+type ResultsSummary struct {
+	Enrolled   int               `json:"enrolled"`
+	Completed  int               `json:"completed"`
+	InProgress int               `json:"inProgress"`
+	NotStarted int               `json:"notStarted"`
+	Mean       float64           `json:"mean"`
+	Median     float64           `json:"median"`
+	Histogram  []HistogramBucket `json:"histogram"`
+}
+
+type TestResultsResponse struct {
+	TestID   uint                    `json:"testId"`
+	Summary  ResultsSummary          `json:"summary"`
+	Students []StudentResultResponse `json:"students"`
+}
+
+// ToTestResultsResponse собирает строку по каждому студенту и сводку.
+// Студент с завершённой попыткой считается завершившим тест и во время
+// пересдачи: его результат - итог по завершённым попыткам. Среднее, медиана
+// и гистограмма считаются по студентам, завершившим тест.
+func (r *TestResults) ToTestResultsResponse() TestResultsResponse {
+	userTests := make(map[uint]*UserTests, len(r.UserTests))
+	for _, ut := range r.UserTests {
+		userTests[ut.UserID] = ut
+	}
+	attempts := make(map[uint][]*Attempt)
+	for _, attempt := range r.Attempts {
+		attempts[attempt.UserID] = append(attempts[attempt.UserID], attempt)
+	}
+
+	response := TestResultsResponse{
+		TestID:   r.TestID,
+		Students: make([]StudentResultResponse, len(r.Students)),
+	}
+	var scores []uint
+
+	for i, student := range r.Students {
+		row := StudentResultResponse{
+			UserID:       student.ID,
+			Username:     student.Username,
+			FirstName:    student.FirstName,
+			LastName:     student.LastName,
+			Status:       NotStarted.String(),
+			AttemptCount: len(attempts[student.ID]),
+		}
+
+		for _, attempt := range attempts[student.ID] {
+			if row.StartedAt == nil || attempt.StartedAt.Before(*row.StartedAt) {
+				startedAt := attempt.StartedAt
+				row.StartedAt = &startedAt
+			}
+			if attempt.FinishedAt != nil && (row.FinishedAt == nil || attempt.FinishedAt.After(*row.FinishedAt)) {
+				row.FinishedAt = attempt.FinishedAt
+			}
+			if attempt.Late {
+				row.Late = true
+			}
+		}
+
+		switch ut := userTests[student.ID]; {
+		case ut == nil:
+			response.Summary.NotStarted++
+		case ut.HasCompletedAttempt():
+			row.Status = Completed.String()
+			score := ut.Progress
+			row.Score = &score
+			scores = append(scores, score)
+			response.Summary.Completed++
+		default:
+			row.Status = ut.Status.String()
+			response.Summary.InProgress++
+		}
+
+		response.Students[i] = row
+	}
+
+	response.Summary.Enrolled = len(r.Students)
+	response.Summary.Mean, response.Summary.Median = meanAndMedian(scores)
+	response.Summary.Histogram = histogram(scores)
+
+	return response
+}
+
+func meanAndMedian(scores []uint) (float64, float64) {
+	if len(scores) == 0 {
+		return 0, 0
+	}
+
+	sorted := append([]uint(nil), scores...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum uint
+	for _, score := range sorted {
+		sum += score
+	}
+	mean := float64(sum) / float64(len(sorted))
+
+	mid := len(sorted) / 2
+	median := float64(sorted[mid])
+	if len(sorted)%2 == 0 {
+		median = float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+
+	return mean, median
+}
+
+// histogram раскладывает результаты по столбцам 0-9, 10-19, ..., 90-100.
+func histogram(scores []uint) []HistogramBucket {
+	buckets := make([]HistogramBucket, 100/histogramBucketWidth)
+	for i := range buckets {
+		buckets[i] = HistogramBucket{
+			From: uint(i * histogramBucketWidth),
+			To:   uint((i+1)*histogramBucketWidth - 1),
+		}
+	}
+	buckets[len(buckets)-1].To = 100
+
+	for _, score := range scores {
+		i := int(score) / histogramBucketWidth
+		if i >= len(buckets) {
+			i = len(buckets) - 1
+		}
+		buckets[i].Count++
+	}
+
+	return buckets
+}
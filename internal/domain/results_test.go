@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeanAndMedian(t *testing.T) {
+	tests := []struct {
+		name       string
+		scores     []uint
+		wantMean   float64
+		wantMedian float64
+	}{
+		{"empty", nil, 0, 0},
+		{"single", []uint{70}, 70, 70},
+		{"odd count unsorted", []uint{90, 10, 50}, 50, 50},
+		{"even count", []uint{40, 100, 60, 80}, 70, 70},
+		{"even count with half", []uint{0, 1}, 0.5, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mean, median := meanAndMedian(tt.scores)
+			if mean != tt.wantMean || median != tt.wantMedian {
+				t.Errorf("meanAndMedian(%v) = (%v, %v), want (%v, %v)", tt.scores, mean, median, tt.wantMean, tt.wantMedian)
+			}
+		})
+	}
+}
+
+func TestMeanAndMedianKeepsInput(t *testing.T) {
+	scores := []uint{30, 10, 20}
+	meanAndMedian(scores)
+	if scores[0] != 30 || scores[1] != 10 || scores[2] != 20 {
+		t.Errorf("meanAndMedian sorted its input: %v", scores)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	buckets := histogram([]uint{0, 9, 10, 55, 89, 90, 99, 100})
+
+	if len(buckets) != 10 {
+		t.Fatalf("len(histogram) = %d, want 10", len(buckets))
+	}
+	if buckets[0].From != 0 || buckets[0].To != 9 || buckets[9].From != 90 || buckets[9].To != 100 {
+		t.Errorf("bucket bounds = %+v ... %+v", buckets[0], buckets[9])
+	}
+
+	want := []int{2, 1, 0, 0, 0, 1, 0, 0, 1, 3}
+	for i, bucket := range buckets {
+		if bucket.Count != want[i] {
+			t.Errorf("bucket %d-%d count = %d, want %d", bucket.From, bucket.To, bucket.Count, want[i])
+		}
+	}
+}
+
+func TestToTestResultsResponseDuringRetake(t *testing.T) {
+	finishedAt := time.Now()
+	results := TestResults{
+		TestID:   1,
+		Students: []*User{{ID: 1}, {ID: 2}, {ID: 3}},
+		UserTests: []*UserTests{
+			{UserID: 1, Status: InProgress, Attempt: 2, Progress: 80},
+			{UserID: 2, Status: InProgress, Attempt: 1},
+		},
+		Attempts: []*Attempt{
+			{UserID: 1, Number: 1, Status: Completed, Score: 80, FinishedAt: &finishedAt},
+			{UserID: 1, Number: 2, Status: InProgress},
+			{UserID: 2, Number: 1, Status: InProgress},
+		},
+	}
+
+	response := results.ToTestResultsResponse()
+
+	retake := response.Students[0]
+	if retake.Status != Completed.String() || retake.Score == nil || *retake.Score != 80 {
+		t.Errorf("retaking student = %s %v, want COMPLETED 80", retake.Status, retake.Score)
+	}
+	if response.Students[1].Status != InProgress.String() || response.Students[1].Score != nil {
+		t.Errorf("first attempt student = %s %v, want IN_PROGRESS without score", response.Students[1].Status, response.Students[1].Score)
+	}
+	if response.Students[2].Status != NotStarted.String() {
+		t.Errorf("not started student = %s, want NOT_STARTED", response.Students[2].Status)
+	}
+
+	summary := response.Summary
+	if summary.Completed != 1 || summary.InProgress != 1 || summary.NotStarted != 1 || summary.Mean != 80 {
+		t.Errorf("summary = %+v, want 1 completed with mean 80, 1 in progress, 1 not started", summary)
+	}
+}
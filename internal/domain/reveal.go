@@ -0,0 +1,107 @@
+package domain
+
+import "diprec_api/internal/pkg/utils"
+
+// RevealPolicy - когда студенту показываются правильные ответы теста:
+// при проверке вопроса, в самом тесте и в разборе попытки.
+type RevealPolicy string
+
+const (
+	// RevealImmediately - ответ на вопрос показывается сразу при его
+	// проверке, а ключ ко всему тесту - после сдачи попытки.
+	RevealImmediately RevealPolicy = "IMMEDIATELY"
+	// RevealAfterSubmit - после сдачи первой попытки. Разбор сданных
+	// попыток доступен и во время пересдачи, но в самой открытой попытке
+	// ключ не показывается.
+	RevealAfterSubmit RevealPolicy = "AFTER_SUBMIT"
+	// RevealAfterDeadline - после закрытия теста, когда истекли все
+	// дедлайны или учитель закрыл его вручную.
+	RevealAfterDeadline RevealPolicy = "AFTER_DEADLINE"
+	RevealNever         RevealPolicy = "NEVER"
+)
+
+func (p RevealPolicy) String() string {
+	return string(p)
+}
+
+// AnswersVisible сообщает, можно ли показать студенту правильные ответы
+// всего теста для разбора сданных попыток. UserTests теста должны быть
+// загружены для этого студента.
+func (c *Test) AnswersVisible() bool {
+	switch c.RevealPolicy {
+	case RevealImmediately, RevealAfterSubmit, "":
+		return c.UserTests.HasCompletedAttempt()
+	case RevealAfterDeadline:
+		return c.Status == Ended
+	}
+
+	return false
+}
+
+// AnswerVisibleOnCheck сообщает, можно ли вернуть правильный ответ при
+// проверке отдельного вопроса. inAttempt - проверенный ответ записан в
+// открытую попытку студента: в ней ключ показывает только IMMEDIATELY, а
+// вне попытки при IMMEDIATELY его можно было бы узнать до начала попытки и
+// затем ответить по нему.
+func (c *Test) AnswerVisibleOnCheck(inAttempt bool) bool {
+	if inAttempt {
+		return c.RevealPolicy == RevealImmediately || c.RevealPolicy == ""
+	}
+	return c.AnswersVisible()
+}
+
+// AttemptReview - ответы студента в завершённой попытке рядом с
+// правильными.
+type AttemptReview struct {
+	Attempt   *Attempt
+	Questions []*Question
+	// Answers - последний ответ студента на каждый вопрос попытки.
+	Answers map[uint]*UserAnswer
+}
+
+type ReviewItemResponse struct {
+	Question   QuestionResponse `json:"question"`
+	UserAnswer interface{}      `json:"userAnswer"`
+	Answered   bool             `json:"answered"`
+	IsCorrect  bool             `json:"isCorrect"`
+	Score      float64          `json:"score"`
+	MaxScore   float64          `json:"maxScore"`
+}
+
+type AttemptReviewResponse struct {
+	Attempt AttemptResponse      `json:"attempt"`
+	Items   []ReviewItemResponse `json:"items"`
+}
+
+func (r *AttemptReview) ToAttemptReviewResponse() AttemptReviewResponse {
+	response := AttemptReviewResponse{
+		Attempt: r.Attempt.ToAttemptResponse(),
+		Items:   make([]ReviewItemResponse, len(r.Questions)),
+	}
+
+	for i, question := range r.Questions {
+		item := ReviewItemResponse{
+			Question: question.ToQuestionResponse(true),
+			MaxScore: question.MaxScore(),
+		}
+		if answer, ok := r.Answers[question.ID]; ok {
+			item.UserAnswer = utils.ParseJSONInterface(answer.Answer)
+			item.Answered = true
+			item.IsCorrect = answer.IsCorrect
+			item.Score = answer.Score
+		}
+		response.Items[i] = item
+	}
+
+	return response
+}
+
+// LatestAnswers оставляет последний ответ на каждый вопрос. answers должны
+// быть упорядочены по времени.
+func LatestAnswers(answers []*UserAnswer) map[uint]*UserAnswer {
+	latest := make(map[uint]*UserAnswer, len(answers))
+	for _, answer := range answers {
+		latest[answer.QuestionID] = answer
+	}
+	return latest
+}
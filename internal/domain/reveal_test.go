@@ -0,0 +1,41 @@
+package domain
+
+import "testing"
+
+func TestTestAnswerVisibility(t *testing.T) {
+	finished := UserTests{Status: Completed}
+	started := UserTests{Status: InProgress, Attempt: 1}
+	retake := UserTests{Status: InProgress, Attempt: 2}
+
+	tests := []struct {
+		name        string
+		test        Test
+		inAttempt   bool
+		wantVisible bool
+		wantOnCheck bool
+	}{
+		{"immediately in attempt", Test{RevealPolicy: RevealImmediately, UserTests: started}, true, false, true},
+		{"immediately without attempt", Test{RevealPolicy: RevealImmediately, UserTests: started}, false, false, false},
+		{"default policy in attempt", Test{UserTests: started}, true, false, true},
+		{"immediately after submit", Test{RevealPolicy: RevealImmediately, UserTests: finished}, false, true, true},
+		{"after submit while in attempt", Test{RevealPolicy: RevealAfterSubmit, UserTests: started}, true, false, false},
+		{"after submit when finished", Test{RevealPolicy: RevealAfterSubmit, UserTests: finished}, false, true, true},
+		{"after submit during retake", Test{RevealPolicy: RevealAfterSubmit, UserTests: retake}, false, true, true},
+		{"after submit in retake attempt", Test{RevealPolicy: RevealAfterSubmit, UserTests: retake}, true, true, false},
+		{"immediately during retake", Test{RevealPolicy: RevealImmediately, UserTests: retake}, true, true, true},
+		{"after deadline in progress", Test{RevealPolicy: RevealAfterDeadline, Status: Progress, UserTests: finished}, false, false, false},
+		{"after deadline when ended", Test{RevealPolicy: RevealAfterDeadline, Status: Ended}, false, true, true},
+		{"never", Test{RevealPolicy: RevealNever, Status: Ended, UserTests: finished}, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.test.AnswersVisible(); got != tt.wantVisible {
+				t.Errorf("AnswersVisible() = %v, want %v", got, tt.wantVisible)
+			}
+			if got := tt.test.AnswerVisibleOnCheck(tt.inAttempt); got != tt.wantOnCheck {
+				t.Errorf("AnswerVisibleOnCheck(%v) = %v, want %v", tt.inAttempt, got, tt.wantOnCheck)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// ScheduledTransition - запланированная автоматическая смена статуса теста:
+// публикация черновика по OpensAt или закрытие по дедлайну.
+type ScheduledTransition struct {
+	TestID   uint
+	TestName string
+	From     TestStatus
+	To       TestStatus
+	At       time.Time
+}
+
+type ScheduledTransitionResponse struct {
+	TestID   uint      `json:"testId"`
+	TestName string    `json:"testName"`
+	From     string    `json:"from" enums:"DRAFT,PROGRESS" example:"DRAFT"`
+	To       string    `json:"to" enums:"PROGRESS,ENDED" example:"PROGRESS"`
+	At       time.Time `json:"at"`
+}
+
+// UpcomingTransitions возвращает предстоящие после now переходы тестов в
+// порядке наступления. closesAt - момент закрытия теста с учётом дедлайнов
+// групп; если для теста его нет, используется дедлайн самого теста.
+func UpcomingTransitions(tests []*Test, closesAt map[uint]time.Time, now time.Time) []*ScheduledTransition {
+	var transitions []*ScheduledTransition
+
+	for _, test := range tests {
+		if test.Status == Draft && test.OpensAt != nil && test.OpensAt.After(now) {
+			transitions = append(transitions, &ScheduledTransition{
+				TestID:   test.ID,
+				TestName: test.Name,
+				From:     Draft,
+				To:       Progress,
+				At:       *test.OpensAt,
+			})
+		}
+
+		if test.Status == Ended || (test.Status == Draft && test.OpensAt == nil) {
+			continue
+		}
+		closeAt, ok := closesAt[test.ID]
+		if !ok {
+			if !test.HasDeadline() {
+				continue
+			}
+			closeAt = test.Deadline
+		}
+		if closeAt.After(now) {
+			transitions = append(transitions, &ScheduledTransition{
+				TestID:   test.ID,
+				TestName: test.Name,
+				From:     Progress,
+				To:       Ended,
+				At:       closeAt,
+			})
+		}
+	}
+
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].At.Before(transitions[j].At)
+	})
+
+	return transitions
+}
+
+func ToScheduledTransitionsResponse(transitions []*ScheduledTransition) []ScheduledTransitionResponse {
+	response := make([]ScheduledTransitionResponse, len(transitions))
+	for i, transition := range transitions {
+		response[i] = ScheduledTransitionResponse{
+			TestID:   transition.TestID,
+			TestName: transition.TestName,
+			From:     transition.From.String(),
+			To:       transition.To.String(),
+			At:       transition.At,
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"diprec_api/internal/pkg/utils"
+	"encoding/json"
+	"math"
+)
+
+// PartialCredit - как начисляются баллы за частично верный ответ на
+// вопрос MULTIPLE. Для остальных типов ответ либо верен, либо нет.
+type PartialCredit string
+
+const (
+	// AllOrNothing - баллы только за полностью совпавший набор вариантов.
+	AllOrNothing PartialCredit = "ALL_OR_NOTHING"
+	// Proportional - доля вариантов, отмеченных верно: выбранных верных
+	// и невыбранных неверных.
+	Proportional PartialCredit = "PROPORTIONAL"
+	// Penalty - доля выбранных верных вариантов, каждый выбранный неверный
+	// вычитает столько же. Результат не меньше нуля.
+	Penalty PartialCredit = "PENALTY"
+)
+
+func (p PartialCredit) String() string {
+	return string(p)
+}
+
+// AnswerScore - набранные баллы и максимум за ответ или за попытку.
+type AnswerScore struct {
+	Score float64 `json:"score"`
+	Max   float64 `json:"maxScore"`
+}
+
+// Correct - набран максимум баллов.
+func (s AnswerScore) Correct() bool {
+	return s.Max > 0 && s.Score >= s.Max
+}
+
+// Percent - результат в процентах от максимума.
+func (s AnswerScore) Percent() uint {
+	if s.Max <= 0 {
+		return 0
+	}
+	return uint(math.Round(s.Score * 100 / s.Max))
+}
+
+// MaxScore - баллы за вопрос в тесте. Без веса вопрос стоит один балл.
+func (q *Question) MaxScore() float64 {
+	if q.Points > 0 {
+		return q.Points
+	}
+	return 1
+}
+
+// Grade оценивает ответ с учётом веса вопроса в тесте и, для MULTIPLE,
+// правила частичного зачёта.
+func (q *Question) Grade(userAnswer interface{}) AnswerScore {
+	score := AnswerScore{Max: q.MaxScore()}
+
+	if q.Type == Multiple {
+		score.Score = round2(score.Max * q.multipleCredit(userAnswer))
+		return score
+	}
+
+	if q.CheckAnswer(userAnswer) {
+		score.Score = score.Max
+	}
+	return score
+}
+
+// multipleCredit - доля баллов за ответ на MULTIPLE по правилу PartialCredit.
+func (q *Question) multipleCredit(userAnswer interface{}) float64 {
+	var correctAnswer interface{}
+	if err := json.Unmarshal(q.Answer, &correctAnswer); err != nil {
+		return 0
+	}
+	correctSlice, ok1 := utils.ToStringSlice(correctAnswer)
+	userSlice, ok2 := utils.ToStringSlice(userAnswer)
+	if !ok1 || !ok2 || len(correctSlice) == 0 {
+		return 0
+	}
+
+	correct := make(map[string]bool, len(correctSlice))
+	for _, key := range correctSlice {
+		correct[key] = true
+	}
+	selected := make(map[string]bool, len(userSlice))
+	for _, key := range userSlice {
+		selected[key] = true
+	}
+
+	var rightPicks, wrongPicks int
+	for key := range selected {
+		if correct[key] {
+			rightPicks++
+		} else {
+			wrongPicks++
+		}
+	}
+
+	switch q.PartialCredit {
+	case Proportional:
+		keys := q.variantKeys()
+		if len(keys) == 0 {
+			return 0
+		}
+		var marked int
+		for _, key := range keys {
+			if correct[key] == selected[key] {
+				marked++
+			}
+		}
+		return float64(marked) / float64(len(keys))
+	case Penalty:
+		return math.Max(0, float64(rightPicks-wrongPicks)/float64(len(correct)))
+	}
+
+	if rightPicks == len(correct) && wrongPicks == 0 {
+		return 1
+	}
+	return 0
+}
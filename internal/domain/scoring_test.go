@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+func jsonValue(t *testing.T, value interface{}) datatypes.JSON {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("marshal %v: %v", value, err)
+	}
+	return raw
+}
+
+func TestQuestionGrade(t *testing.T) {
+	variants := map[string]string{"a": "A", "b": "B", "c": "C", "d": "D"}
+
+	tests := []struct {
+		name     string
+		question Question
+		answer   interface{}
+		want     AnswerScore
+	}{
+		{
+			name:     "single correct",
+			question: Question{Type: Single, Answer: jsonValue(t, "a")},
+			answer:   "a",
+			want:     AnswerScore{Score: 1, Max: 1},
+		},
+		{
+			name:     "single wrong with weight",
+			question: Question{Type: Single, Answer: jsonValue(t, "a"), Points: 3},
+			answer:   "b",
+			want:     AnswerScore{Score: 0, Max: 3},
+		},
+		{
+			name:     "text ignores case and spaces",
+			question: Question{Type: Text, Answer: jsonValue(t, "Москва")},
+			answer:   "  москва ",
+			want:     AnswerScore{Score: 1, Max: 1},
+		},
+		{
+			name:     "number with weight",
+			question: Question{Type: Number, Answer: jsonValue(t, 42), Points: 2.5},
+			answer:   float64(42),
+			want:     AnswerScore{Score: 2.5, Max: 2.5},
+		},
+		{
+			name:     "multiple all or nothing partial",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b"}), Variants: jsonValue(t, variants), PartialCredit: AllOrNothing},
+			answer:   []interface{}{"a"},
+			want:     AnswerScore{Score: 0, Max: 1},
+		},
+		{
+			name:     "multiple all or nothing exact in any order",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b"}), Variants: jsonValue(t, variants), PartialCredit: AllOrNothing},
+			answer:   []interface{}{"b", "a"},
+			want:     AnswerScore{Score: 1, Max: 1},
+		},
+		{
+			name:     "multiple proportional counts unselected wrong variants",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b"}), Variants: jsonValue(t, variants), PartialCredit: Proportional, Points: 4},
+			answer:   []interface{}{"a", "c"},
+			want:     AnswerScore{Score: 2, Max: 4},
+		},
+		{
+			name:     "multiple proportional empty answer",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b"}), Variants: jsonValue(t, variants), PartialCredit: Proportional},
+			answer:   []interface{}{},
+			want:     AnswerScore{Score: 0.5, Max: 1},
+		},
+		{
+			name:     "multiple penalty subtracts wrong picks",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b", "c"}), Variants: jsonValue(t, variants), PartialCredit: Penalty, Points: 3},
+			answer:   []interface{}{"a", "b", "d"},
+			want:     AnswerScore{Score: 1, Max: 3},
+		},
+		{
+			name:     "multiple penalty is not negative",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a"}), Variants: jsonValue(t, variants), PartialCredit: Penalty},
+			answer:   []interface{}{"b", "c"},
+			want:     AnswerScore{Score: 0, Max: 1},
+		},
+		{
+			name:     "multiple penalty rounds to hundredths",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a", "b", "c"}), Variants: jsonValue(t, variants), PartialCredit: Penalty},
+			answer:   []interface{}{"a"},
+			want:     AnswerScore{Score: 0.33, Max: 1},
+		},
+		{
+			name:     "multiple with malformed answer",
+			question: Question{Type: Multiple, Answer: jsonValue(t, []string{"a"}), Variants: jsonValue(t, variants), PartialCredit: Proportional},
+			answer:   "a",
+			want:     AnswerScore{Score: 0, Max: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.question.Grade(tt.answer); got != tt.want {
+				t.Errorf("Grade(%v) = %+v, want %+v", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerScore(t *testing.T) {
+	tests := []struct {
+		score       AnswerScore
+		wantCorrect bool
+		wantPercent uint
+	}{
+		{AnswerScore{Score: 0, Max: 0}, false, 0},
+		{AnswerScore{Score: 1, Max: 1}, true, 100},
+		{AnswerScore{Score: 1, Max: 3}, false, 33},
+		{AnswerScore{Score: 2, Max: 3}, false, 67},
+	}
+
+	for _, tt := range tests {
+		if got := tt.score.Correct(); got != tt.wantCorrect {
+			t.Errorf("%+v.Correct() = %v, want %v", tt.score, got, tt.wantCorrect)
+		}
+		if got := tt.score.Percent(); got != tt.wantPercent {
+			t.Errorf("%+v.Percent() = %d, want %d", tt.score, got, tt.wantPercent)
+		}
+	}
+}
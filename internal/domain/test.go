@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,6 +20,44 @@ type Test struct {
 	Courses     []*Course   `gorm:"many2many:course_tests;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Questions   []*Question `gorm:"many2many:test_questions;constraint:OnUpdate:CASCADE;OnDelete:CASCADE;"`
 	UserTests   UserTests   `gorm:"foreignKey:test_id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// MaxAttempts - сколько раз студент может начать тест, 0 - без ограничений.
+	MaxAttempts   uint          `gorm:"not null;default:0"`
+	ScoringPolicy ScoringPolicy `gorm:"type:varchar(20);not null;scoring_policy IN ('BEST', 'LAST', 'AVERAGE');default:'LAST'"`
+	RevealPolicy  RevealPolicy  `gorm:"type:varchar(20);not null;reveal_policy IN ('IMMEDIATELY', 'AFTER_SUBMIT', 'AFTER_DEADLINE', 'NEVER');default:'IMMEDIATELY'"`
+	// TimeLimit - время на одну попытку в минутах, 0 - без ограничения.
+	TimeLimit uint `gorm:"not null;default:0"`
+	// OpensAt - когда черновик будет автоматически запущен, nil - вручную.
+	OpensAt *time.Time `gorm:"index"`
+	// ShuffleQuestions и ShuffleVariants перемешивают для студента порядок
+	// вопросов и вариантов ответа. Указатели - чтобы Update мог их выключить.
+	ShuffleQuestions *bool `gorm:"not null;default:false"`
+	ShuffleVariants  *bool `gorm:"not null;default:false"`
+	// Practice - на вопросах завершённого теста можно тренироваться без
+	// влияния на результат.
+	Practice *bool `gorm:"not null;default:false"`
+	// Adaptive - вопросы выдаются по одному в зависимости от ответов, а
+	// результат - оценка способности студента. AdaptiveMaxItems и
+	// AdaptiveTargetSE - правила остановки: число вопросов и стандартная
+	// ошибка оценки, 0 - без ограничения.
+	Adaptive         *bool   `gorm:"not null;default:false"`
+	AdaptiveMaxItems uint    `gorm:"not null;default:0"`
+	AdaptiveTargetSE float64 `gorm:"not null;default:0"`
+	// LateHours - сколько часов после дедлайна ещё принимаются попытки,
+	// LatePenalty и LatePenaltyPercent - как за это снижается результат.
+	LateHours          uint        `gorm:"not null;default:0"`
+	LatePenalty        LatePenalty `gorm:"type:varchar(20);not null;late_penalty IN ('NONE', 'LINEAR', 'STEPPED');default:'NONE'"`
+	LatePenaltyPercent uint        `gorm:"not null;default:0"`
+	// Pools - правила случайной выборки вопросов в дополнение к Questions.
+	Pools []*QuestionPool `gorm:"foreignKey:TestID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// Unavailable - тест закрыт для текущего студента переопределением его группы.
+	Unavailable bool `gorm:"-"`
+	// Locked - не выполнены предварительные условия теста или его курса.
+	Locked       bool                   `gorm:"-"`
+	Requirements []PrerequisiteResponse `gorm:"-"`
+	// Extended - студенту продлён дедлайн индивидуально.
+	Extended bool `gorm:"-"`
+	// AnswersRevealed - студенту уже можно показать правильные ответы.
+	AnswersRevealed bool `gorm:"-"`
 }
 
 type TestStatus string
@@ -24,23 +65,58 @@ type TestStatus string
 const (
 	Draft    TestStatus = "DRAFT"
 	Progress TestStatus = "PROGRESS"
-	Ended               = "ENDED"
+	Ended    TestStatus = "ENDED"
 )
 
+// testStatusTransitions - допустимые переходы статуса теста. Завершённый
+// тест обратно не открывается.
+var testStatusTransitions = map[TestStatus][]TestStatus{
+	Draft:    {Progress},
+	Progress: {Ended},
+}
+
 func (t TestStatus) String() string {
 	return string(t)
 }
 
+func (t TestStatus) CanTransitionTo(to TestStatus) bool {
+	for _, allowed := range testStatusTransitions[t] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 type TestResponse struct {
-	ID               uint      `json:"id"`
-	Name             string    `json:"name"`
-	Description      string    `json:"description"`
-	Status           string    `json:"status"`
-	Assignee         string    `json:"assignee"`
-	Deadline         time.Time `json:"deadline"`
-	CreatedAt        time.Time `json:"createdAt"`
-	UpdatedAt        time.Time `json:"updatedAt"`
-	UserTestResponse `json:"result,omitempty"`
+	ID                 uint                   `json:"id"`
+	Name               string                 `json:"name"`
+	Description        string                 `json:"description"`
+	Status             string                 `json:"status"`
+	Assignee           string                 `json:"assignee"`
+	Deadline           time.Time              `json:"deadline"`
+	MaxAttempts        uint                   `json:"maxAttempts"`
+	ScoringPolicy      string                 `json:"scoringPolicy" enums:"BEST,LAST,AVERAGE" example:"LAST"`
+	RevealPolicy       string                 `json:"revealPolicy" enums:"IMMEDIATELY,AFTER_SUBMIT,AFTER_DEADLINE,NEVER" example:"AFTER_SUBMIT"`
+	TimeLimit          uint                   `json:"timeLimit"`
+	OpensAt            *time.Time             `json:"opensAt,omitempty"`
+	ShuffleQuestions   bool                   `json:"shuffleQuestions"`
+	ShuffleVariants    bool                   `json:"shuffleVariants"`
+	LateHours          uint                   `json:"lateHours"`
+	LatePenalty        string                 `json:"latePenalty" enums:"NONE,LINEAR,STEPPED" example:"NONE"`
+	LatePenaltyPercent uint                   `json:"latePenaltyPercent"`
+	Practice           bool                   `json:"practice"`
+	Adaptive           bool                   `json:"adaptive"`
+	AdaptiveMaxItems   uint                   `json:"adaptiveMaxItems,omitempty"`
+	AdaptiveTargetSE   float64                `json:"adaptiveTargetSE,omitempty"`
+	Extended           bool                   `json:"extended,omitempty"`
+	Pools              []QuestionPoolResponse `json:"pools,omitempty"`
+	Available          bool                   `json:"available"`
+	Locked             bool                   `json:"locked"`
+	Requirements       []PrerequisiteResponse `json:"requirements,omitempty"`
+	CreatedAt          time.Time              `json:"createdAt"`
+	UpdatedAt          time.Time              `json:"updatedAt"`
+	UserTestResponse   `json:"result,omitempty"`
 }
 
 type TestResponseWithQuestions struct {
@@ -59,24 +135,243 @@ func (a Assignee) String() string {
 	return string(a)
 }
 
+// ApplyGroupOverrides подставляет эффективные дедлайн и доступность теста
+// с учётом групп студента. Если студент состоит в нескольких группах,
+// действует самый поздний дедлайн, а тест доступен, если его открыла
+// хотя бы одна из групп.
+func (c *Test) ApplyGroupOverrides(overrides []*GroupTest) {
+	var deadline *time.Time
+	var available *bool
+
+	for _, override := range overrides {
+		if override.TestID != c.ID {
+			continue
+		}
+		if override.Deadline != nil && (deadline == nil || override.Deadline.After(*deadline)) {
+			deadline = override.Deadline
+		}
+		if override.Available != nil && (available == nil || *override.Available) {
+			available = override.Available
+		}
+	}
+
+	if deadline != nil {
+		c.Deadline = *deadline
+	}
+	if available != nil {
+		c.Unavailable = !*available
+	}
+}
+
+// Score считает баллы попытки по листу ответов с учётом весов вопросов.
+// Учитываются только вопросы, которые сейчас прикреплены к тесту; вопрос
+// без ответа приносит ноль баллов.
+func (c *Test) Score(answers []*UserTestAnswer) AnswerScore {
+	credit := make(map[uint]float64, len(answers))
+	for _, answer := range answers {
+		credit[answer.QuestionID] = answer.Credit()
+	}
+
+	var total AnswerScore
+	for _, question := range c.Questions {
+		total.Max += question.MaxScore()
+		total.Score += question.MaxScore() * credit[question.ID]
+	}
+	total.Score = round2(total.Score)
+
+	return total
+}
+
+// CheckTransition проверяет, можно ли перевести тест в статус to: переход
+// должен быть разрешён, а запускаемый тест - содержать вопросы.
+// Questions теста должны быть загружены.
+func (c *Test) CheckTransition(to TestStatus) error {
+	if !c.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, c.Status, to)
+	}
+	if to == Progress && len(c.Questions) == 0 && !c.UsesPools() {
+		return ErrTestHasNoQuestions
+	}
+
+	return nil
+}
+
+// Duplicate возвращает копию настроек теста под новым именем в статусе
+// черновика. Дедлайн и время публикации сдвигаются на shift. Вопросы,
+// пулы, курсы и результаты не копируются.
+func (c *Test) Duplicate(name string, shift time.Duration) *Test {
+	duplicate := &Test{
+		Name:               name,
+		Description:        c.Description,
+		Status:             Draft,
+		Assignee:           Teacher,
+		Deadline:           c.Deadline,
+		MaxAttempts:        c.MaxAttempts,
+		ScoringPolicy:      c.ScoringPolicy,
+		RevealPolicy:       c.RevealPolicy,
+		TimeLimit:          c.TimeLimit,
+		ShuffleQuestions:   c.ShuffleQuestions,
+		ShuffleVariants:    c.ShuffleVariants,
+		Practice:           c.Practice,
+		LateHours:          c.LateHours,
+		LatePenalty:        c.LatePenalty,
+		LatePenaltyPercent: c.LatePenaltyPercent,
+		Adaptive:           c.Adaptive,
+		AdaptiveMaxItems:   c.AdaptiveMaxItems,
+		AdaptiveTargetSE:   c.AdaptiveTargetSE,
+	}
+	if c.HasDeadline() {
+		duplicate.Deadline = c.Deadline.Add(shift)
+	}
+	if c.OpensAt != nil {
+		opensAt := c.OpensAt.Add(shift)
+		duplicate.OpensAt = &opensAt
+	}
+
+	return duplicate
+}
+
+// HasDeadline сообщает, задан ли у теста дедлайн.
+func (c *Test) HasDeadline() bool {
+	return !c.Deadline.IsZero()
+}
+
+// DeadlinePassed сообщает, закрыт ли приём попыток к моменту now: истёк
+// дедлайн и окно поздней сдачи.
+func (c *Test) DeadlinePassed(now time.Time) bool {
+	return c.HasDeadline() && now.After(c.ClosesAt())
+}
+
+// AttemptExpiry возвращает момент окончания попытки, начатой в startedAt:
+// по лимиту времени, но не позже дедлайна теста. Nil - попытка не ограничена.
+func (c *Test) AttemptExpiry(startedAt time.Time) *time.Time {
+	var expiresAt *time.Time
+
+	if c.TimeLimit > 0 {
+		limit := startedAt.Add(time.Duration(c.TimeLimit) * time.Minute)
+		expiresAt = &limit
+	}
+	if c.HasDeadline() && (expiresAt == nil || c.ClosesAt().Before(*expiresAt)) {
+		closesAt := c.ClosesAt()
+		expiresAt = &closesAt
+	}
+
+	return expiresAt
+}
+
+// ShuffleFor перемешивает вопросы и варианты ответов для студента userID,
+// если это включено в настройках теста. Порядок детерминирован для пары
+// студент-попытка, поэтому при перезагрузке страницы не меняется, а в
+// новой попытке становится другим. Ключи вариантов не меняются, так что
+// проверка ответов работает как прежде. Попытки должны быть загружены в
+// UserTests.Attempts.
+func (c *Test) ShuffleFor(userID uint) {
+	seed := shuffleSeed(c.ID, userID, c.CurrentAttemptID())
+
+	if c.ShuffleQuestions != nil && *c.ShuffleQuestions {
+		rnd := rand.New(rand.NewSource(seed))
+		rnd.Shuffle(len(c.Questions), func(i, j int) {
+			c.Questions[i], c.Questions[j] = c.Questions[j], c.Questions[i]
+		})
+	}
+
+	if c.ShuffleVariants != nil && *c.ShuffleVariants {
+		for _, question := range c.Questions {
+			// от вопроса зависит своё зерно, чтобы порядок вариантов
+			// не зависел от перемешивания вопросов
+			question.ShuffleVariants(rand.New(rand.NewSource(seed ^ int64(question.ID))))
+		}
+	}
+}
+
+// HasQuestion сообщает, есть ли вопрос среди загруженных вопросов теста.
+func (c *Test) HasQuestion(questionID uint) bool {
+	for _, question := range c.Questions {
+		if question.ID == questionID {
+			return true
+		}
+	}
+	return false
+}
+
+// PracticeAvailable сообщает, можно ли тренироваться на вопросах теста:
+// тест завершён, учитель включил для него практику и политика показа
+// ответов не запрещает их показывать.
+func (c *Test) PracticeAvailable() bool {
+	return c.Status == Ended && c.Practice != nil && *c.Practice && c.RevealPolicy != RevealNever
+}
+
+// AddQuestions добавляет к вопросам теста ещё не входящие в него.
+func (c *Test) AddQuestions(questions []*Question) {
+	for _, question := range questions {
+		if !c.HasQuestion(question.ID) {
+			c.Questions = append(c.Questions, question)
+		}
+	}
+}
+
+// CurrentAttemptID - открытая попытка студента, иначе последняя, иначе 0.
+func (c *Test) CurrentAttemptID() uint {
+	var current *Attempt
+	for _, attempt := range c.UserTests.Attempts {
+		if attempt.Status == InProgress {
+			return attempt.ID
+		}
+		if current == nil || attempt.Number > current.Number {
+			current = attempt
+		}
+	}
+	if current == nil {
+		return 0
+	}
+	return current.ID
+}
+
+func shuffleSeed(testID, userID, attemptID uint) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%d:%d", testID, userID, attemptID)
+	return int64(h.Sum64())
+}
+
 func (c *Test) ToTestResponse() TestResponse {
 	return TestResponse{
-		ID:               c.ID,
-		Name:             c.Name,
-		Description:      c.Description,
-		Status:           c.Status.String(),
-		Assignee:         c.Assignee.String(),
-		Deadline:         c.Deadline,
-		CreatedAt:        c.CreatedAt,
-		UpdatedAt:        c.UpdatedAt,
-		UserTestResponse: c.UserTests.ToUserTestResponse(),
+		ID:                 c.ID,
+		Name:               c.Name,
+		Description:        c.Description,
+		Status:             c.Status.String(),
+		Assignee:           c.Assignee.String(),
+		Deadline:           c.Deadline,
+		MaxAttempts:        c.MaxAttempts,
+		ScoringPolicy:      c.ScoringPolicy.String(),
+		RevealPolicy:       c.RevealPolicy.String(),
+		TimeLimit:          c.TimeLimit,
+		OpensAt:            c.OpensAt,
+		ShuffleQuestions:   c.ShuffleQuestions != nil && *c.ShuffleQuestions,
+		ShuffleVariants:    c.ShuffleVariants != nil && *c.ShuffleVariants,
+		Practice:           c.Practice != nil && *c.Practice,
+		Adaptive:           c.IsAdaptive(),
+		AdaptiveMaxItems:   c.AdaptiveMaxItems,
+		AdaptiveTargetSE:   c.AdaptiveTargetSE,
+		LateHours:          c.LateHours,
+		LatePenalty:        c.LatePenalty.String(),
+		LatePenaltyPercent: c.LatePenaltyPercent,
+		Extended:           c.Extended,
+		Pools:              ToQuestionPoolsResponse(c.Pools),
+		Available:          !c.Unavailable,
+		Locked:             c.Locked,
+		Requirements:       c.Requirements,
+		CreatedAt:          c.CreatedAt,
+		UpdatedAt:          c.UpdatedAt,
+		UserTestResponse:   c.UserTests.ToUserTestResponse(),
 	}
 }
 
+// ToTestResponseWithQuestions показывает правильные ответы учителю, а
+// студенту - если это уже разрешено политикой теста.
 func (c *Test) ToTestResponseWithQuestions(isTeacher bool) TestResponseWithQuestions {
 	return TestResponseWithQuestions{
 		TestResponse: c.ToTestResponse(),
-		Questions:    ToQuestionsResponse(c.Questions, isTeacher),
+		Questions:    ToQuestionsResponse(c.Questions, isTeacher || c.AnswersRevealed),
 	}
 }
 
@@ -0,0 +1,405 @@
+package domain
+
+import (
+	"diprec_api/internal/pkg/utils"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	// TestPackageFormat и TestPackageVersion - формат пакета экспорта теста.
+	// Версия повышается при несовместимом изменении структуры пакета.
+	TestPackageFormat  = "diprec.test"
+	TestPackageVersion = 1
+)
+
+// TestPackage - самодостаточное описание теста для переноса между
+// экземплярами сервиса: настройки, вопросы с ответами в порядке теста,
+// их веса и пулы. Вопросы ссылаются друг на друга только ключами пакета.
+type TestPackage struct {
+	Format     string              `json:"format" example:"diprec.test"`
+	Version    int                 `json:"version" example:"1"`
+	ExportedAt time.Time           `json:"exportedAt"`
+	Test       TestPackageSettings `json:"test"`
+	// Questions - вопросы теста по порядку, затем вопросы только для пулов.
+	Questions []PackageQuestion `json:"questions"`
+	Pools     []PackagePool     `json:"pools,omitempty"`
+}
+
+type TestPackageSettings struct {
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	Deadline           *time.Time `json:"deadline,omitempty"`
+	MaxAttempts        uint       `json:"maxAttempts"`
+	ScoringPolicy      string     `json:"scoringPolicy" enums:"BEST,LAST,AVERAGE"`
+	RevealPolicy       string     `json:"revealPolicy" enums:"IMMEDIATELY,AFTER_SUBMIT,AFTER_DEADLINE,NEVER"`
+	TimeLimit          uint       `json:"timeLimit"`
+	ShuffleQuestions   bool       `json:"shuffleQuestions"`
+	ShuffleVariants    bool       `json:"shuffleVariants"`
+	LateHours          uint       `json:"lateHours"`
+	LatePenalty        string     `json:"latePenalty" enums:"NONE,LINEAR,STEPPED"`
+	LatePenaltyPercent uint       `json:"latePenaltyPercent"`
+	Adaptive           bool       `json:"adaptive,omitempty"`
+	AdaptiveMaxItems   uint       `json:"adaptiveMaxItems,omitempty"`
+	AdaptiveTargetSE   float64    `json:"adaptiveTargetSE,omitempty"`
+}
+
+type PackageQuestion struct {
+	// Key - ключ вопроса внутри пакета, на него ссылаются пулы.
+	Key           string                 `json:"key" example:"q12"`
+	Title         string                 `json:"title"`
+	Type          string                 `json:"type" enums:"SINGLE,MULTIPLE,TEXT,NUMBER"`
+	Variants      map[string]interface{} `json:"variants,omitempty"`
+	Answer        interface{}            `json:"answer"`
+	Tag           string                 `json:"tag,omitempty"`
+	Difficulty    uint                   `json:"difficulty,omitempty"`
+	PartialCredit string                 `json:"partialCredit,omitempty" enums:"ALL_OR_NOTHING,PROPORTIONAL,PENALTY"`
+	// Points - вес вопроса в тесте.
+	Points float64 `json:"points,omitempty"`
+	// PoolOnly - вопрос не прикреплён к тесту и нужен только пулам.
+	PoolOnly bool `json:"poolOnly,omitempty"`
+}
+
+type PackagePool struct {
+	Tag          string   `json:"tag,omitempty"`
+	QuestionKeys []string `json:"questionKeys,omitempty"`
+	Count        uint     `json:"count"`
+	Difficulty   *uint    `json:"difficulty,omitempty"`
+}
+
+// NewTestPackage собирает пакет теста. poolQuestions - вопросы из наборов
+// пулов, которые не прикреплены к тесту.
+func NewTestPackage(test *Test, poolQuestions []*Question, now time.Time) *TestPackage {
+	pkg := &TestPackage{
+		Format:     TestPackageFormat,
+		Version:    TestPackageVersion,
+		ExportedAt: now,
+		Test: TestPackageSettings{
+			Name:               test.Name,
+			Description:        test.Description,
+			MaxAttempts:        test.MaxAttempts,
+			ScoringPolicy:      test.ScoringPolicy.String(),
+			RevealPolicy:       test.RevealPolicy.String(),
+			TimeLimit:          test.TimeLimit,
+			ShuffleQuestions:   test.ShuffleQuestions != nil && *test.ShuffleQuestions,
+			ShuffleVariants:    test.ShuffleVariants != nil && *test.ShuffleVariants,
+			LateHours:          test.LateHours,
+			LatePenalty:        test.LatePenalty.String(),
+			LatePenaltyPercent: test.LatePenaltyPercent,
+			Adaptive:           test.IsAdaptive(),
+			AdaptiveMaxItems:   test.AdaptiveMaxItems,
+			AdaptiveTargetSE:   test.AdaptiveTargetSE,
+		},
+		Questions: make([]PackageQuestion, 0, len(test.Questions)+len(poolQuestions)),
+	}
+	if test.HasDeadline() {
+		deadline := test.Deadline
+		pkg.Test.Deadline = &deadline
+	}
+
+	for _, question := range test.Questions {
+		exported := newPackageQuestion(question)
+		exported.Points = question.MaxScore()
+		pkg.Questions = append(pkg.Questions, exported)
+	}
+	for _, question := range poolQuestions {
+		exported := newPackageQuestion(question)
+		exported.PoolOnly = true
+		pkg.Questions = append(pkg.Questions, exported)
+	}
+
+	// Удалённые вопросы из наборов пулов в пакет не попадают.
+	exported := make(map[string]bool, len(pkg.Questions))
+	for _, question := range pkg.Questions {
+		exported[question.Key] = true
+	}
+	for _, pool := range test.Pools {
+		exportedPool := PackagePool{Tag: pool.Tag, Count: pool.Count, Difficulty: pool.Difficulty}
+		for _, id := range pool.QuestionIDs {
+			if key := packageQuestionKey(id); exported[key] {
+				exportedPool.QuestionKeys = append(exportedPool.QuestionKeys, key)
+			}
+		}
+		pkg.Pools = append(pkg.Pools, exportedPool)
+	}
+
+	return pkg
+}
+
+func newPackageQuestion(question *Question) PackageQuestion {
+	exported := PackageQuestion{
+		Key:        packageQuestionKey(question.ID),
+		Title:      question.Title,
+		Type:       question.Type.String(),
+		Variants:   utils.ParseJSONToMap(question.Variants),
+		Answer:     utils.ParseJSONInterface(question.Answer),
+		Tag:        question.Tag,
+		Difficulty: question.Difficulty,
+	}
+	if question.Type == Multiple {
+		exported.PartialCredit = question.PartialCredit.String()
+	}
+	return exported
+}
+
+func packageQuestionKey(id uint) string {
+	return fmt.Sprintf("q%d", id)
+}
+
+// PoolQuestionIDs - вопросы из наборов пулов теста, не прикреплённые к нему.
+func (c *Test) PoolQuestionIDs() []uint {
+	seen := make(map[uint]bool, len(c.Questions))
+	for _, question := range c.Questions {
+		seen[question.ID] = true
+	}
+
+	var ids []uint
+	for _, pool := range c.Pools {
+		for _, id := range pool.QuestionIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// Validate проверяет формат и версию пакета, ключи и типы вопросов и
+// ссылки пулов на вопросы.
+func (p *TestPackage) Validate() error {
+	if p.Format != TestPackageFormat || p.Version != TestPackageVersion {
+		return fmt.Errorf("%w: %s v%d", ErrUnsupportedPackage, p.Format, p.Version)
+	}
+
+	switch ScoringPolicy(p.Test.ScoringPolicy) {
+	case "", ScoreBest, ScoreLast, ScoreAverage:
+	default:
+		return fmt.Errorf("%w: неизвестная политика оценки %q", ErrInvalidTestPackage, p.Test.ScoringPolicy)
+	}
+	switch RevealPolicy(p.Test.RevealPolicy) {
+	case "", RevealImmediately, RevealAfterSubmit, RevealAfterDeadline, RevealNever:
+	default:
+		return fmt.Errorf("%w: неизвестная политика показа ответов %q", ErrInvalidTestPackage, p.Test.RevealPolicy)
+	}
+	switch LatePenalty(p.Test.LatePenalty) {
+	case "", NoLatePenalty, LinearPenalty, SteppedPenalty:
+	default:
+		return fmt.Errorf("%w: неизвестный штраф за опоздание %q", ErrInvalidTestPackage, p.Test.LatePenalty)
+	}
+
+	keys := make(map[string]bool, len(p.Questions))
+	for _, question := range p.Questions {
+		if question.Key == "" || keys[question.Key] {
+			return fmt.Errorf("%w: ключ вопроса %q пуст или повторяется", ErrInvalidTestPackage, question.Key)
+		}
+		keys[question.Key] = true
+
+		switch Type(question.Type) {
+		case Single, Multiple, Text, Number:
+		default:
+			return fmt.Errorf("%w: неизвестный тип вопроса %q", ErrInvalidTestPackage, question.Type)
+		}
+
+		switch PartialCredit(question.PartialCredit) {
+		case "", AllOrNothing, Proportional, Penalty:
+		default:
+			return fmt.Errorf("%w: неизвестное правило зачёта %q", ErrInvalidTestPackage, question.PartialCredit)
+		}
+
+		if question.Points < 0 {
+			return fmt.Errorf("%w: отрицательный вес вопроса %q", ErrInvalidTestPackage, question.Key)
+		}
+	}
+
+	for _, pool := range p.Pools {
+		for _, key := range pool.QuestionKeys {
+			if !keys[key] {
+				return fmt.Errorf("%w: пул ссылается на неизвестный вопрос %q", ErrInvalidTestPackage, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckMapping проверяет, что явное сопоставление ссылается только на
+// вопросы пакета.
+func (p *TestPackage) CheckMapping(mapping map[string]uint) error {
+	keys := make(map[string]bool, len(p.Questions))
+	for _, question := range p.Questions {
+		keys[question.Key] = true
+	}
+	for key := range mapping {
+		if !keys[key] {
+			return fmt.Errorf("%w: в пакете нет вопроса %q", ErrInvalidTestPackage, key)
+		}
+	}
+
+	return nil
+}
+
+// NewTest создаёт по пакету черновик теста с именем name. Незаданные
+// политики получают значения по умолчанию.
+func (p *TestPackage) NewTest(name string) *Test {
+	settings := p.Test
+	test := &Test{
+		Name:               name,
+		Description:        settings.Description,
+		Status:             Draft,
+		Assignee:           Teacher,
+		MaxAttempts:        settings.MaxAttempts,
+		ScoringPolicy:      ScoringPolicy(settings.ScoringPolicy),
+		RevealPolicy:       RevealPolicy(settings.RevealPolicy),
+		TimeLimit:          settings.TimeLimit,
+		ShuffleQuestions:   &settings.ShuffleQuestions,
+		ShuffleVariants:    &settings.ShuffleVariants,
+		LateHours:          settings.LateHours,
+		LatePenalty:        LatePenalty(settings.LatePenalty),
+		LatePenaltyPercent: settings.LatePenaltyPercent,
+		Adaptive:           &settings.Adaptive,
+		AdaptiveMaxItems:   settings.AdaptiveMaxItems,
+		AdaptiveTargetSE:   settings.AdaptiveTargetSE,
+	}
+	if settings.Deadline != nil {
+		test.Deadline = *settings.Deadline
+	}
+	if test.ScoringPolicy == "" {
+		test.ScoringPolicy = ScoreLast
+	}
+	if test.RevealPolicy == "" {
+		test.RevealPolicy = RevealImmediately
+	}
+	if test.LatePenalty == "" {
+		test.LatePenalty = NoLatePenalty
+	}
+
+	return test
+}
+
+// Question создаёт по вопросу пакета новый вопрос.
+func (q *PackageQuestion) Question() *Question {
+	question := &Question{
+		Title:         q.Title,
+		Type:          Type(q.Type),
+		Variants:      utils.ParseMapToJSON(q.Variants),
+		Answer:        utils.ParseToJSON(q.Answer),
+		Tag:           q.Tag,
+		Difficulty:    q.Difficulty,
+		PartialCredit: PartialCredit(q.PartialCredit),
+	}
+	if question.PartialCredit == "" {
+		question.PartialCredit = AllOrNothing
+	}
+
+	return question
+}
+
+// SameContent сообщает, совпадает ли вопрос пакета с question по типу,
+// вариантам, правильному ответу и правилу зачёта.
+func (q *PackageQuestion) SameContent(question *Question) bool {
+	imported := q.Question()
+	if imported.Type != question.Type {
+		return false
+	}
+	if imported.Type == Multiple && imported.PartialCredit != question.PartialCredit {
+		return false
+	}
+
+	return reflect.DeepEqual(utils.ParseJSONToMap(imported.Variants), utils.ParseJSONToMap(question.Variants)) &&
+		reflect.DeepEqual(utils.ParseJSONInterface(imported.Answer), utils.ParseJSONInterface(question.Answer))
+}
+
+// QuestionConflictPolicy - что делать, если в базе уже есть вопрос с тем же
+// заголовком и типом, но другим содержимым.
+type QuestionConflictPolicy string
+
+const (
+	// ConflictCreate - создать вопрос из пакета рядом с существующим.
+	ConflictCreate QuestionConflictPolicy = "CREATE"
+	// ConflictUseExisting - взять существующий вопрос вместо вопроса пакета.
+	ConflictUseExisting QuestionConflictPolicy = "USE_EXISTING"
+)
+
+// ImportStatus - как при импорте сопоставлен вопрос пакета.
+type ImportStatus string
+
+const (
+	// ImportCreated - вопрос создан.
+	ImportCreated ImportStatus = "CREATED"
+	// ImportReused - найден такой же вопрос, он и использован.
+	ImportReused ImportStatus = "REUSED"
+	// ImportMapped - вопрос явно сопоставлен существующему в запросе.
+	ImportMapped ImportStatus = "MAPPED"
+	// ImportConflict - есть вопрос с тем же заголовком и другим содержимым,
+	// конфликт решён по QuestionConflictPolicy.
+	ImportConflict ImportStatus = "CONFLICT"
+)
+
+// TestImportOptions - параметры импорта пакета в курс.
+type TestImportOptions struct {
+	CourseID uint
+	// Name и Deadline заменяют значения из пакета, если заданы.
+	Name     string
+	Deadline *time.Time
+	// Mapping - явное сопоставление ключей пакета существующим вопросам.
+	Mapping    map[string]uint
+	OnConflict QuestionConflictPolicy
+}
+
+// ImportedQuestion - итог импорта одного вопроса пакета.
+type ImportedQuestion struct {
+	Key        string       `json:"key"`
+	Title      string       `json:"title"`
+	QuestionID uint         `json:"questionId"`
+	Status     ImportStatus `json:"status" enums:"CREATED,REUSED,MAPPED,CONFLICT"`
+	// ConflictID - существующий вопрос, с которым возник конфликт.
+	ConflictID uint `json:"conflictId,omitempty"`
+}
+
+// ResolveImportedQuestion выбирает для вопроса пакета существующий вопрос
+// среди candidates - вопросов с тем же заголовком и типом. Nil - вопрос
+// нужно создать.
+func ResolveImportedQuestion(question *PackageQuestion, candidates []*Question, policy QuestionConflictPolicy) (*Question, ImportedQuestion) {
+	result := ImportedQuestion{Key: question.Key, Title: question.Title, Status: ImportCreated}
+
+	for _, candidate := range candidates {
+		if question.SameContent(candidate) {
+			result.Status = ImportReused
+			result.QuestionID = candidate.ID
+			return candidate, result
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, result
+	}
+
+	result.Status = ImportConflict
+	result.ConflictID = candidates[0].ID
+	if policy == ConflictUseExisting {
+		result.QuestionID = candidates[0].ID
+		return candidates[0], result
+	}
+
+	return nil, result
+}
+
+// TestImport - результат импорта пакета.
+type TestImport struct {
+	Test      *Test
+	Questions []ImportedQuestion
+}
+
+type TestImportResponse struct {
+	Test      TestResponse       `json:"test"`
+	Questions []ImportedQuestion `json:"questions"`
+}
+
+func (i *TestImport) ToTestImportResponse() TestImportResponse {
+	return TestImportResponse{
+		Test:      i.Test.ToTestResponse(),
+		Questions: i.Questions,
+	}
+}
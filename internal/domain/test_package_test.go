@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func validPackage() *TestPackage {
+	return &TestPackage{
+		Format:  TestPackageFormat,
+		Version: TestPackageVersion,
+		Test:    TestPackageSettings{Name: "Go", ScoringPolicy: "BEST", RevealPolicy: "NEVER", LatePenalty: "LINEAR"},
+		Questions: []PackageQuestion{
+			{Key: "q1", Title: "Один", Type: "SINGLE", Answer: "a", Points: 2},
+			{Key: "q2", Title: "Несколько", Type: "MULTIPLE", Answer: []string{"a"}, PartialCredit: "PENALTY"},
+			{Key: "q3", Title: "Из пула", Type: "NUMBER", Answer: 3, PoolOnly: true},
+		},
+		Pools: []PackagePool{{QuestionKeys: []string{"q2", "q3"}, Count: 1}},
+	}
+}
+
+func TestTestPackageValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(pkg *TestPackage)
+		wantErr error
+	}{
+		{"valid", func(pkg *TestPackage) {}, nil},
+		{"empty policies", func(pkg *TestPackage) { pkg.Test = TestPackageSettings{Name: "Go"} }, nil},
+		{"other format", func(pkg *TestPackage) { pkg.Format = "other" }, ErrUnsupportedPackage},
+		{"newer version", func(pkg *TestPackage) { pkg.Version = TestPackageVersion + 1 }, ErrUnsupportedPackage},
+		{"unknown scoring policy", func(pkg *TestPackage) { pkg.Test.ScoringPolicy = "WORST" }, ErrInvalidTestPackage},
+		{"unknown reveal policy", func(pkg *TestPackage) { pkg.Test.RevealPolicy = "SOMETIMES" }, ErrInvalidTestPackage},
+		{"unknown late penalty", func(pkg *TestPackage) { pkg.Test.LatePenalty = "DOUBLE" }, ErrInvalidTestPackage},
+		{"empty key", func(pkg *TestPackage) { pkg.Questions[0].Key = "" }, ErrInvalidTestPackage},
+		{"duplicate key", func(pkg *TestPackage) { pkg.Questions[1].Key = "q1" }, ErrInvalidTestPackage},
+		{"unknown question type", func(pkg *TestPackage) { pkg.Questions[0].Type = "ESSAY" }, ErrInvalidTestPackage},
+		{"unknown partial credit", func(pkg *TestPackage) { pkg.Questions[1].PartialCredit = "HALF" }, ErrInvalidTestPackage},
+		{"negative points", func(pkg *TestPackage) { pkg.Questions[0].Points = -1 }, ErrInvalidTestPackage},
+		{"pool references unknown key", func(pkg *TestPackage) { pkg.Pools[0].QuestionKeys = []string{"q9"} }, ErrInvalidTestPackage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := validPackage()
+			tt.modify(pkg)
+
+			err := pkg.Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTestPackageCheckMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping map[string]uint
+		wantErr bool
+	}{
+		{"no mapping", nil, false},
+		{"known keys", map[string]uint{"q1": 10, "q3": 12}, false},
+		{"unknown key", map[string]uint{"q1": 10, "q7": 12}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validPackage().CheckMapping(tt.mapping)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidTestPackage)) {
+				t.Errorf("CheckMapping() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTestPackageSkipsMissingPoolQuestions(t *testing.T) {
+	test := &Test{
+		Name:      "Go",
+		Questions: []*Question{{ID: 1, Type: Single, Points: 2}},
+		Pools:     []*QuestionPool{{Count: 1, QuestionIDs: []uint{1, 2, 3}}},
+	}
+	pkg := NewTestPackage(test, []*Question{{ID: 2, Type: Number}}, time.Now())
+
+	if err := pkg.Validate(); err != nil {
+		t.Fatalf("exported package is invalid: %v", err)
+	}
+	if len(pkg.Questions) != 2 || pkg.Questions[0].Points != 2 || !pkg.Questions[1].PoolOnly {
+		t.Errorf("questions = %+v", pkg.Questions)
+	}
+	if keys := pkg.Pools[0].QuestionKeys; len(keys) != 2 || keys[0] != "q1" || keys[1] != "q2" {
+		t.Errorf("pool keys = %v, want [q1 q2]", keys)
+	}
+}
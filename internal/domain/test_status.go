@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// StatusChangeReason - что вызвало смену статуса теста.
+type StatusChangeReason string
+
+const (
+	ReasonManual   StatusChangeReason = "MANUAL"
+	ReasonSchedule StatusChangeReason = "SCHEDULE"
+	ReasonDeadline StatusChangeReason = "DEADLINE"
+)
+
+func (r StatusChangeReason) String() string {
+	return string(r)
+}
+
+// TestStatusChange - запись истории статусов теста. ChangedBy - преподаватель,
+// сменивший статус; для автоматических переходов он пустой.
+type TestStatusChange struct {
+	ID        uint               `gorm:"primaryKey;autoIncrement"`
+	TestID    uint               `gorm:"not null;index"`
+	From      TestStatus         `gorm:"column:from_status;type:varchar(20);not null"`
+	To        TestStatus         `gorm:"column:to_status;type:varchar(20);not null"`
+	Reason    StatusChangeReason `gorm:"type:varchar(20);not null"`
+	ChangedBy *uint
+	CreatedAt time.Time
+}
+
+type TestStatusChangeResponse struct {
+	From      string    `json:"from" enums:"DRAFT,PROGRESS,ENDED" example:"DRAFT"`
+	To        string    `json:"to" enums:"DRAFT,PROGRESS,ENDED" example:"PROGRESS"`
+	Reason    string    `json:"reason" enums:"MANUAL,SCHEDULE,DEADLINE" example:"MANUAL"`
+	ChangedBy *uint     `json:"changedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func ToTestStatusChangesResponse(changes []*TestStatusChange) []TestStatusChangeResponse {
+	response := make([]TestStatusChangeResponse, len(changes))
+	for i, change := range changes {
+		response[i] = TestStatusChangeResponse{
+			From:      change.From.String(),
+			To:        change.To.String(),
+			Reason:    change.Reason.String(),
+			ChangedBy: change.ChangedBy,
+			CreatedAt: change.CreatedAt,
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTestCheckTransition(t *testing.T) {
+	questions := []*Question{{ID: 1}}
+	pools := []*QuestionPool{{ID: 1, Tag: "go", Count: 1}}
+
+	tests := []struct {
+		name    string
+		test    Test
+		to      TestStatus
+		wantErr error
+	}{
+		{"draft to progress", Test{Status: Draft, Questions: questions}, Progress, nil},
+		{"draft with pools only", Test{Status: Draft, Pools: pools}, Progress, nil},
+		{"draft without questions", Test{Status: Draft}, Progress, ErrTestHasNoQuestions},
+		{"progress to ended", Test{Status: Progress}, Ended, nil},
+		{"draft to ended", Test{Status: Draft, Questions: questions}, Ended, ErrInvalidStatusTransition},
+		{"ended is final", Test{Status: Ended, Questions: questions}, Progress, ErrInvalidStatusTransition},
+		{"progress back to draft", Test{Status: Progress, Questions: questions}, Draft, ErrInvalidStatusTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.test.CheckTransition(tt.to)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("CheckTransition(%s) = %v, want %v", tt.to, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTestApplyGroupOverrides(t *testing.T) {
+	deadline := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	earlier := deadline.Add(-24 * time.Hour)
+	later := deadline.Add(24 * time.Hour)
+	yes, no := true, false
+
+	tests := []struct {
+		name            string
+		overrides       []*GroupTest
+		wantDeadline    time.Time
+		wantUnavailable bool
+	}{
+		{
+			name:         "no overrides",
+			wantDeadline: deadline,
+		},
+		{
+			name:         "other test is ignored",
+			overrides:    []*GroupTest{{TestID: 2, Deadline: &later, Available: &no}},
+			wantDeadline: deadline,
+		},
+		{
+			name:         "group deadline replaces test deadline even if earlier",
+			overrides:    []*GroupTest{{TestID: 1, Deadline: &earlier}},
+			wantDeadline: earlier,
+		},
+		{
+			name:         "latest group deadline wins",
+			overrides:    []*GroupTest{{TestID: 1, Deadline: &earlier}, {TestID: 1, Deadline: &later}},
+			wantDeadline: later,
+		},
+		{
+			name:            "unavailable for the only group",
+			overrides:       []*GroupTest{{TestID: 1, Available: &no}},
+			wantDeadline:    deadline,
+			wantUnavailable: true,
+		},
+		{
+			name:         "available in any group wins",
+			overrides:    []*GroupTest{{TestID: 1, Available: &no}, {TestID: 1, Available: &yes}, {TestID: 1, Available: &no}},
+			wantDeadline: deadline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &Test{ID: 1, Deadline: deadline}
+			test.ApplyGroupOverrides(tt.overrides)
+			if !test.Deadline.Equal(tt.wantDeadline) || test.Unavailable != tt.wantUnavailable {
+				t.Errorf("ApplyGroupOverrides() = deadline %v, unavailable %v; want %v, %v",
+					test.Deadline, test.Unavailable, tt.wantDeadline, tt.wantUnavailable)
+			}
+		})
+	}
+}
+
+func TestTestPracticeAvailable(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name string
+		test Test
+		want bool
+	}{
+		{"ended with practice", Test{Status: Ended, Practice: &enabled}, true},
+		{"practice not set", Test{Status: Ended}, false},
+		{"practice disabled", Test{Status: Ended, Practice: &disabled}, false},
+		{"test still in progress", Test{Status: Progress, Practice: &enabled}, false},
+		{"answers never revealed", Test{Status: Ended, Practice: &enabled, RevealPolicy: RevealNever}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.test.PracticeAvailable(); got != tt.want {
+				t.Errorf("PracticeAvailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestAddQuestions(t *testing.T) {
+	test := &Test{Questions: []*Question{{ID: 1}, {ID: 2}}}
+	test.AddQuestions([]*Question{{ID: 2}, {ID: 3}, {ID: 3}})
+
+	var ids []uint
+	for _, question := range test.Questions {
+		ids = append(ids, question.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("questions after AddQuestions = %v, want [1 2 3]", ids)
+	}
+}
+
+func TestTestDuplicate(t *testing.T) {
+	enabled := true
+	deadline := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	opensAt := deadline.Add(-48 * time.Hour)
+	shift := 7 * 24 * time.Hour
+
+	source := &Test{
+		ID:                 3,
+		Name:               "Контрольная",
+		Description:        "Описание",
+		Status:             Ended,
+		Assignee:           Recommendation,
+		Deadline:           deadline,
+		OpensAt:            &opensAt,
+		MaxAttempts:        2,
+		ScoringPolicy:      ScoreBest,
+		RevealPolicy:       RevealAfterDeadline,
+		TimeLimit:          45,
+		ShuffleQuestions:   &enabled,
+		ShuffleVariants:    &enabled,
+		Practice:           &enabled,
+		Adaptive:           &enabled,
+		AdaptiveMaxItems:   20,
+		AdaptiveTargetSE:   0.3,
+		LateHours:          24,
+		LatePenalty:        LinearPenalty,
+		LatePenaltyPercent: 10,
+		Questions:          []*Question{{ID: 1}},
+		Pools:              []*QuestionPool{{ID: 1, Count: 1}},
+		UserTests:          UserTests{UserID: 5, Status: Completed, Attempts: []*Attempt{{ID: 9}}},
+	}
+
+	got := source.Duplicate("Контрольная (копия)", shift)
+
+	shiftedOpensAt := opensAt.Add(shift)
+	want := &Test{
+		Name:               "Контрольная (копия)",
+		Description:        "Описание",
+		Status:             Draft,
+		Assignee:           Teacher,
+		Deadline:           deadline.Add(shift),
+		OpensAt:            &shiftedOpensAt,
+		MaxAttempts:        2,
+		ScoringPolicy:      ScoreBest,
+		RevealPolicy:       RevealAfterDeadline,
+		TimeLimit:          45,
+		ShuffleQuestions:   &enabled,
+		ShuffleVariants:    &enabled,
+		Practice:           &enabled,
+		Adaptive:           &enabled,
+		AdaptiveMaxItems:   20,
+		AdaptiveTargetSE:   0.3,
+		LateHours:          24,
+		LatePenalty:        LinearPenalty,
+		LatePenaltyPercent: 10,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicate() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// AdvisoryLocker даёт выполнить работу только одной из реплик сервиса,
+// используя advisory-блокировки Postgres. Блокировка живёт в транзакции и
+// снимается при её завершении, в том числе при обрыве соединения.
+type AdvisoryLocker struct {
+	db *gorm.DB
+}
+
+func NewAdvisoryLocker(db *gorm.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+// TryWithLock выполняет fn, если удалось взять блокировку key, и сообщает,
+// была ли она взята. Если блокировку держит другая реплика, fn не вызывается.
+func (l *AdvisoryLocker) TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	var acquired bool
+
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+
+		return fn(ctx)
+	})
+
+	return acquired, err
+}
@@ -53,9 +53,25 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.CourseTest{},
 		&domain.UserCourse{},
 		&domain.UserTests{},
+		&domain.UserTestAnswer{},
+		&domain.UserAnswer{},
+		&domain.Attempt{},
+		&domain.AttemptQuestion{},
+		&domain.AttemptDraft{},
+		&domain.GroupUser{},
+		&domain.GroupTest{},
 		&domain.User{},
 		&domain.Course{},
 		&domain.Test{},
 		&domain.Question{},
+		&domain.Group{},
+		&domain.TestPrerequisite{},
+		&domain.CoursePrerequisite{},
+		&domain.TestStatusChange{},
+		&domain.TestExtension{},
+		&domain.RecommendedTest{},
+		&domain.QuestionPool{},
+		&domain.QuestionStats{},
+		&domain.TestStatsRun{},
 	)
 }
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job - периодическая фоновая задача. Ошибка одного запуска только
+// логируется, следующий запуск произойдёт по расписанию.
+type Job func(ctx context.Context) error
+
+// Locker не даёт одной задаче выполняться одновременно на нескольких
+// репликах сервиса.
+type Locker interface {
+	TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+type task struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler запускает фоновые задачи с заданным интервалом до отмены
+// контекста. Состояние задач хранится в базе, поэтому после перезапуска
+// первый проход сразу догоняет всё пропущенное.
+type Scheduler struct {
+	tasks  []task
+	locker Locker
+	logger *zap.Logger
+}
+
+// New создаёт планировщик. Если locker не nil, каждый проход задачи
+// выполняется только на одной реплике.
+func New(locker Locker, logger *zap.Logger) *Scheduler {
+	return &Scheduler{locker: locker, logger: logger}
+}
+
+// Add регистрирует задачу. Задачи с неположительным интервалом отключены.
+func (s *Scheduler) Add(name string, interval time.Duration, job Job) {
+	if interval <= 0 {
+		s.logger.Info("Scheduled job disabled", zap.String("job", name))
+		return
+	}
+	s.tasks = append(s.tasks, task{name: name, interval: interval, job: job})
+}
+
+// Start запускает все задачи в отдельных горутинах и сразу возвращается.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, t := range s.tasks {
+		go s.run(ctx, t)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, t task) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.runOnce(ctx, t); err != nil {
+			s.logger.Error("Scheduled job failed", zap.String("job", t.name), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, t task) error {
+	if s.locker == nil {
+		return t.job(ctx)
+	}
+
+	acquired, err := s.locker.TryWithLock(ctx, lockKey(t.name), t.job)
+	if !acquired && err == nil {
+		s.logger.Debug("Scheduled job is running on another replica", zap.String("job", t.name))
+	}
+	return err
+}
+
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
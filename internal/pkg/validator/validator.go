@@ -4,9 +4,16 @@ import (
 	"reflect"
 )
 
-func BuildUpdates(model interface{}) map[string]interface{} {
+// BuildUpdates собирает изменения модели по её ненулевым полям. Поля из
+// reset попадают в изменения и с нулевым значением - так их можно сбросить.
+func BuildUpdates(model interface{}, reset ...string) map[string]interface{} {
 	updates := make(map[string]interface{})
 
+	resetFields := make(map[string]bool, len(reset))
+	for _, name := range reset {
+		resetFields[name] = true
+	}
+
 	v := reflect.ValueOf(model)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
@@ -24,7 +31,7 @@ func BuildUpdates(model interface{}) map[string]interface{} {
 		field := t.Field(i)
 		value := v.Field(i)
 
-		if skipFields[field.Name] || !value.IsValid() || isZero(value) {
+		if skipFields[field.Name] || !value.IsValid() || (isZero(value) && !resetFields[field.Name]) {
 			continue
 		}
 
@@ -0,0 +1,55 @@
+package answer
+
+import (
+	"context"
+	"diprec_api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type answerRepository struct {
+	db *gorm.DB
+}
+
+type IAnswerRepository interface {
+	Create(ctx context.Context, answer *domain.UserAnswer) error
+	GetByTest(ctx context.Context, testID, userID uint) ([]*domain.UserAnswer, error)
+	GetByAttempt(ctx context.Context, attemptID uint) ([]*domain.UserAnswer, error)
+}
+
+func NewAnswerRepository(db *gorm.DB) IAnswerRepository {
+	return &answerRepository{db: db}
+}
+
+func (r *answerRepository) Create(ctx context.Context, answer *domain.UserAnswer) error {
+	return r.db.Create(answer).Error
+}
+
+// GetByTest возвращает историю ответов по тесту в порядке отправки.
+// Если userID равен нулю, возвращаются ответы всех студентов.
+func (r *answerRepository) GetByTest(ctx context.Context, testID, userID uint) ([]*domain.UserAnswer, error) {
+	var answers []*domain.UserAnswer
+
+	query := r.db.Where("test_id = ?", testID)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Order("created_at, id").Find(&answers).Error; err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
+
+// GetByAttempt возвращает ответы, данные в попытке, в порядке отправки.
+func (r *answerRepository) GetByAttempt(ctx context.Context, attemptID uint) ([]*domain.UserAnswer, error) {
+	var answers []*domain.UserAnswer
+
+	err := r.db.Where("attempt_id = ?", attemptID).Order("created_at, id").Find(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
@@ -5,6 +5,7 @@ import (
 	"diprec_api/internal/domain"
 	"diprec_api/internal/pkg/validator"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,10 +17,14 @@ type courseRepository struct {
 type ICourseRepository interface {
 	Create(ctx context.Context, course *domain.Course) error
 	Get(ctx context.Context) ([]*domain.Course, error)
-	GetByID(ctx context.Context, id, userID uint) (*domain.Course, error)
+	GetByID(ctx context.Context, id, userID uint, staff bool) (*domain.Course, error)
 	Update(ctx context.Context, course *domain.Course) error
 	Delete(ctx context.Context, id uint) error
 	EnrollUser(ctx context.Context, courseID uint, userID uint) error
+	Exists(ctx context.Context, id uint) (bool, error)
+	IsEnrolled(ctx context.Context, courseID uint, userID uint) (bool, error)
+	GetGroupOverrides(ctx context.Context, courseID, userID uint) ([]*domain.GroupTest, error)
+	GetExtensions(ctx context.Context, courseID, userID uint) ([]*domain.TestExtension, error)
 }
 
 func NewCourseRepository(db *gorm.DB) ICourseRepository { return &courseRepository{db: db} }
@@ -44,11 +49,23 @@ func (r *courseRepository) Get(ctx context.Context) ([]*domain.Course, error) {
 	return courses, nil
 }
 
-func (r *courseRepository) GetByID(ctx context.Context, id, userID uint) (*domain.Course, error) {
+// GetByID загружает курс с тестами и прогрессом пользователя userID по ним.
+// staff - курс читает его персонал: ему видны все тесты рекомендаций.
+func (r *courseRepository) GetByID(ctx context.Context, id, userID uint, staff bool) (*domain.Course, error) {
 	var course domain.Course
 
+	tests := []interface{}{"deleted_at IS NULL"}
+	if !staff {
+		// тесты рекомендаций видны только своему студенту до истечения срока
+		tests = []interface{}{`deleted_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM recommended_tests
+			WHERE recommended_tests.test_id = tests.id
+				AND (recommended_tests.user_id <> ? OR recommended_tests.expires_at <= ?)
+		)`, userID, time.Now()}
+	}
+
 	err := r.db.
-		Preload("Tests", "deleted_at IS NULL").
+		Preload("Tests", tests...).
 		Preload("Tests.UserTests", "user_id = ?", userID).
 		First(&course, id).Error
 
@@ -98,3 +115,59 @@ func (r *courseRepository) EnrollUser(ctx context.Context, courseID uint, userID
 
 	return nil
 }
+
+func (r *courseRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&domain.Course{}).Where("id = ?", id).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *courseRepository) IsEnrolled(ctx context.Context, courseID uint, userID uint) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&domain.UserCourse{}).
+		Where("course_id = ? AND user_id = ?", courseID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetGroupOverrides возвращает переопределения тестов курса для всех
+// групп курса, в которых состоит пользователь.
+func (r *courseRepository) GetGroupOverrides(ctx context.Context, courseID, userID uint) ([]*domain.GroupTest, error) {
+	var overrides []*domain.GroupTest
+
+	err := r.db.
+		Joins("JOIN group_users ON group_users.group_id = group_tests.group_id").
+		Joins("JOIN groups ON groups.id = group_tests.group_id AND groups.deleted_at IS NULL").
+		Where("groups.course_id = ? AND group_users.user_id = ?", courseID, userID).
+		Find(&overrides).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+// GetExtensions возвращает продления дедлайнов пользователя по тестам курса.
+func (r *courseRepository) GetExtensions(ctx context.Context, courseID, userID uint) ([]*domain.TestExtension, error) {
+	var extensions []*domain.TestExtension
+
+	err := r.db.
+		Joins("JOIN course_tests ON course_tests.test_id = test_extensions.test_id").
+		Where("course_tests.course_id = ? AND test_extensions.user_id = ?", courseID, userID).
+		Find(&extensions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return extensions, nil
+}
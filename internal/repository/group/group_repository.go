@@ -0,0 +1,177 @@
+package group
+
+import (
+	"context"
+	"diprec_api/internal/domain"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+type IGroupRepository interface {
+	Create(ctx context.Context, group *domain.Group) error
+	GetByCourseID(ctx context.Context, courseID uint) ([]*domain.Group, error)
+	GetByID(ctx context.Context, id uint) (*domain.Group, error)
+	Delete(ctx context.Context, id uint) error
+	AddUsers(ctx context.Context, groupID uint, userIDs []uint) error
+	RemoveUser(ctx context.Context, groupID uint, userID uint) error
+	SetTestOverride(ctx context.Context, override *domain.GroupTest) error
+	DeleteTestOverride(ctx context.Context, groupID uint, testID uint) error
+	GetNotEnrolled(ctx context.Context, courseID uint, userIDs []uint) ([]uint, error)
+	IsCourseTest(ctx context.Context, courseID uint, testID uint) (bool, error)
+}
+
+func NewGroupRepository(db *gorm.DB) IGroupRepository { return &groupRepository{db: db} }
+
+func (r *groupRepository) Create(ctx context.Context, group *domain.Group) error {
+	var course domain.Course
+	if err := r.db.First(&course, group.CourseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrCourseNotFound
+		}
+		return err
+	}
+
+	return r.db.Create(group).Error
+}
+
+func (r *groupRepository) GetByCourseID(ctx context.Context, courseID uint) ([]*domain.Group, error) {
+	var groups []*domain.Group
+
+	err := r.db.
+		Preload("Users", "deleted_at IS NULL").
+		Preload("Overrides").
+		Where("course_id = ?", courseID).
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id uint) (*domain.Group, error) {
+	var group domain.Group
+
+	err := r.db.
+		Preload("Users", "deleted_at IS NULL").
+		Preload("Overrides").
+		First(&group, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrGroupNotFound
+		}
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *groupRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&domain.GroupUser{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&domain.GroupTest{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&domain.Group{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrGroupNotFound
+		}
+		return nil
+	})
+}
+
+func (r *groupRepository) AddUsers(ctx context.Context, groupID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	members := make([]domain.GroupUser, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = domain.GroupUser{GroupID: groupID, UserID: userID}
+	}
+
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+}
+
+func (r *groupRepository) RemoveUser(ctx context.Context, groupID uint, userID uint) error {
+	return r.db.
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&domain.GroupUser{}).Error
+}
+
+func (r *groupRepository) SetTestOverride(ctx context.Context, override *domain.GroupTest) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "group_id"}, {Name: "test_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"deadline", "available"}),
+		}).
+		Create(override).Error
+}
+
+func (r *groupRepository) DeleteTestOverride(ctx context.Context, groupID uint, testID uint) error {
+	return r.db.
+		Where("group_id = ? AND test_id = ?", groupID, testID).
+		Delete(&domain.GroupTest{}).Error
+}
+
+// GetNotEnrolled возвращает тех из userIDs, кто не записан на курс.
+func (r *groupRepository) GetNotEnrolled(ctx context.Context, courseID uint, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var enrolled []uint
+	err := r.db.Model(&domain.UserCourse{}).
+		Where("course_id = ? AND user_id IN ?", courseID, userIDs).
+		Pluck("user_id", &enrolled).Error
+	if err != nil {
+		return nil, err
+	}
+
+	isEnrolled := make(map[uint]bool, len(enrolled))
+	for _, userID := range enrolled {
+		isEnrolled[userID] = true
+	}
+
+	var missing []uint
+	for _, userID := range userIDs {
+		if !isEnrolled[userID] {
+			missing = append(missing, userID)
+		}
+	}
+	return missing, nil
+}
+
+// IsCourseTest сообщает, относится ли тест к курсу. Несуществующий тест -
+// ErrTestNotFound.
+func (r *groupRepository) IsCourseTest(ctx context.Context, courseID uint, testID uint) (bool, error) {
+	var test domain.Test
+	if err := r.db.Select("id").First(&test, testID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, domain.ErrTestNotFound
+		}
+		return false, err
+	}
+
+	var count int64
+	err := r.db.Model(&domain.CourseTest{}).
+		Where("course_id = ? AND test_id = ?", courseID, testID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,140 @@
+package prerequisite
+
+import (
+	"context"
+	"diprec_api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type prerequisiteRepository struct {
+	db *gorm.DB
+}
+
+type IPrerequisiteRepository interface {
+	SetTestPrerequisites(ctx context.Context, testID uint, prerequisites []*domain.TestPrerequisite) error
+	SetCoursePrerequisites(ctx context.Context, courseID uint, prerequisites []*domain.CoursePrerequisite) error
+	GetTestPrerequisites(ctx context.Context, testIDs []uint) ([]*domain.TestPrerequisite, error)
+	GetCoursePrerequisites(ctx context.Context, courseIDs []uint) ([]*domain.CoursePrerequisite, error)
+	GetAllTestPrerequisites(ctx context.Context) ([]*domain.TestPrerequisite, error)
+	GetAllCoursePrerequisites(ctx context.Context) ([]*domain.CoursePrerequisite, error)
+	GetCourseTests(ctx context.Context, courseIDs []uint) ([]*domain.CourseTest, error)
+	GetTestCourses(ctx context.Context, testIDs []uint) ([]*domain.CourseTest, error)
+	GetUserTests(ctx context.Context, userID uint, testIDs []uint) ([]*domain.UserTests, error)
+}
+
+func NewPrerequisiteRepository(db *gorm.DB) IPrerequisiteRepository {
+	return &prerequisiteRepository{db: db}
+}
+
+func (r *prerequisiteRepository) SetTestPrerequisites(ctx context.Context, testID uint, prerequisites []*domain.TestPrerequisite) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("test_id = ?", testID).Delete(&domain.TestPrerequisite{}).Error; err != nil {
+			return err
+		}
+		if len(prerequisites) == 0 {
+			return nil
+		}
+		return tx.Omit("RequiredTest").Create(&prerequisites).Error
+	})
+}
+
+func (r *prerequisiteRepository) SetCoursePrerequisites(ctx context.Context, courseID uint, prerequisites []*domain.CoursePrerequisite) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", courseID).Delete(&domain.CoursePrerequisite{}).Error; err != nil {
+			return err
+		}
+		if len(prerequisites) == 0 {
+			return nil
+		}
+		return tx.Omit("RequiredCourse").Create(&prerequisites).Error
+	})
+}
+
+func (r *prerequisiteRepository) GetTestPrerequisites(ctx context.Context, testIDs []uint) ([]*domain.TestPrerequisite, error) {
+	var prerequisites []*domain.TestPrerequisite
+
+	err := r.db.
+		Preload("RequiredTest").
+		Where("test_id IN ?", testIDs).
+		Find(&prerequisites).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+func (r *prerequisiteRepository) GetCoursePrerequisites(ctx context.Context, courseIDs []uint) ([]*domain.CoursePrerequisite, error) {
+	var prerequisites []*domain.CoursePrerequisite
+
+	err := r.db.
+		Preload("RequiredCourse").
+		Where("course_id IN ?", courseIDs).
+		Find(&prerequisites).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+func (r *prerequisiteRepository) GetAllTestPrerequisites(ctx context.Context) ([]*domain.TestPrerequisite, error) {
+	var prerequisites []*domain.TestPrerequisite
+
+	if err := r.db.Find(&prerequisites).Error; err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+func (r *prerequisiteRepository) GetAllCoursePrerequisites(ctx context.Context) ([]*domain.CoursePrerequisite, error) {
+	var prerequisites []*domain.CoursePrerequisite
+
+	if err := r.db.Find(&prerequisites).Error; err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+// GetCourseTests возвращает связи курсов с опубликованными тестами,
+// назначенными преподавателем. Рекомендованные тесты и черновики в прогресс
+// по курсу не входят: студент не может их пройти.
+func (r *prerequisiteRepository) GetCourseTests(ctx context.Context, courseIDs []uint) ([]*domain.CourseTest, error) {
+	var links []*domain.CourseTest
+
+	err := r.db.
+		Joins("JOIN tests ON tests.id = course_tests.test_id AND tests.deleted_at IS NULL").
+		Where("course_tests.course_id IN ? AND tests.assignee = ? AND tests.status <> ?", courseIDs, domain.Teacher, domain.Draft).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (r *prerequisiteRepository) GetTestCourses(ctx context.Context, testIDs []uint) ([]*domain.CourseTest, error) {
+	var links []*domain.CourseTest
+
+	if err := r.db.Where("test_id IN ?", testIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (r *prerequisiteRepository) GetUserTests(ctx context.Context, userID uint, testIDs []uint) ([]*domain.UserTests, error) {
+	var userTests []*domain.UserTests
+
+	err := r.db.
+		Where("user_id = ? AND test_id IN ?", userID, testIDs).
+		Find(&userTests).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return userTests, nil
+}
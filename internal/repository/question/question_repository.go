@@ -49,6 +49,9 @@ func (r *questionRepository) GetByID(ctx context.Context, id uint) (*domain.Ques
 
 	err := r.db.First(&question, id).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrQuestionNotFound
+		}
 		return nil, err
 	}
 
@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"context"
+	"diprec_api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+type IStatsRepository interface {
+	GetStaleTestIDs(ctx context.Context) ([]uint, error)
+	GetTestResponses(ctx context.Context, testID uint) ([]*domain.ItemResponse, error)
+	GetQuestionResponses(ctx context.Context, questionIDs []uint) ([]*domain.ItemResponse, error)
+	GetQuestions(ctx context.Context, questionIDs []uint) ([]*domain.Question, error)
+	Replace(ctx context.Context, testID uint, questionIDs []uint, stats []*domain.QuestionStats, computedAt time.Time) error
+	GetByTest(ctx context.Context, testID uint) ([]*domain.QuestionStats, error)
+	GetByQuestion(ctx context.Context, questionID uint) ([]*domain.QuestionStats, error)
+}
+
+func NewStatsRepository(db *gorm.DB) IStatsRepository {
+	return &statsRepository{db: db}
+}
+
+// GetStaleTestIDs возвращает тесты, в которых завершились попытки после
+// последнего расчёта статистики. Для тестов, посчитанных до появления
+// test_stats_runs, момент расчёта берётся из question_stats.
+func (r *statsRepository) GetStaleTestIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.
+		Model(&domain.Attempt{}).
+		Distinct("test_id").
+		Where("status = ?", domain.Completed).
+		Where(`finished_at > COALESCE(
+			(SELECT computed_at FROM test_stats_runs WHERE test_stats_runs.test_id = attempts.test_id),
+			(SELECT MAX(computed_at) FROM question_stats WHERE question_stats.test_id = attempts.test_id),
+			'-infinity')`).
+		Pluck("test_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *statsRepository) GetTestResponses(ctx context.Context, testID uint) ([]*domain.ItemResponse, error) {
+	return r.responses(r.db.Where("user_answers.test_id = ?", testID))
+}
+
+func (r *statsRepository) GetQuestionResponses(ctx context.Context, questionIDs []uint) ([]*domain.ItemResponse, error) {
+	return r.responses(r.db.Where("user_answers.question_id IN ?", questionIDs))
+}
+
+// responses возвращает ответы из завершённых попыток в порядке отправки.
+func (r *statsRepository) responses(query *gorm.DB) ([]*domain.ItemResponse, error) {
+	var responses []*domain.ItemResponse
+
+	err := query.
+		Model(&domain.UserAnswer{}).
+		Select("user_answers.*, attempts.score AS attempt_score, attempts.started_at AS attempt_started_at").
+		Joins("JOIN attempts ON attempts.id = user_answers.attempt_id").
+		Where("attempts.status = ?", domain.Completed).
+		Order("user_answers.created_at, user_answers.id").
+		Scan(&responses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+func (r *statsRepository) GetQuestions(ctx context.Context, questionIDs []uint) ([]*domain.Question, error) {
+	var questions []*domain.Question
+
+	if err := r.db.Where("id IN ?", questionIDs).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// Replace заменяет статистику вопросов questionIDs в разрезе теста testID
+// (0 - по всем тестам). Для теста запоминается момент расчёта computedAt.
+func (r *statsRepository) Replace(ctx context.Context, testID uint, questionIDs []uint, stats []*domain.QuestionStats, computedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("test_id = ?", testID)
+		if testID == 0 {
+			query = query.Where("question_id IN ?", questionIDs)
+		}
+		if err := query.Delete(&domain.QuestionStats{}).Error; err != nil {
+			return err
+		}
+
+		if testID > 0 {
+			run := &domain.TestStatsRun{TestID: testID, ComputedAt: computedAt}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "test_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"computed_at"}),
+			}).Create(run).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(stats) == 0 {
+			return nil
+		}
+		return tx.Create(&stats).Error
+	})
+}
+
+func (r *statsRepository) GetByTest(ctx context.Context, testID uint) ([]*domain.QuestionStats, error) {
+	var stats []*domain.QuestionStats
+
+	if err := r.db.Where("test_id = ?", testID).Order("question_id").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetByQuestion возвращает статистику вопроса по всем тестам (первой, с
+// TestID = 0) и в разрезе каждого теста.
+func (r *statsRepository) GetByQuestion(ctx context.Context, questionID uint) ([]*domain.QuestionStats, error) {
+	var stats []*domain.QuestionStats
+
+	if err := r.db.Where("question_id = ?", questionID).Order("test_id").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -6,6 +6,7 @@ import (
 	"diprec_api/internal/pkg/validator"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -19,14 +20,56 @@ type ITestRepository interface {
 	Create(ctx context.Context, test *domain.Test, courseID uint) error
 	Get(ctx context.Context, courseID, userID uint) ([]*domain.Test, error)
 	GetByID(ctx context.Context, id, userID uint) (*domain.Test, error)
-	Update(ctx context.Context, test *domain.Test) error
+	Update(ctx context.Context, test *domain.Test, reset ...string) error
 	Delete(ctx context.Context, id uint) error
-	AttachQuestion(ctx context.Context, testID uint, questionID uint) error
+	AttachQuestion(ctx context.Context, testID uint, questionID uint, index *int, points float64) error
+	ReorderQuestions(ctx context.Context, testID uint, questionIDs []uint) error
+	ApplyQuestionBatch(ctx context.Context, testID uint, batch *domain.QuestionBatch) error
 	DetachQuestion(ctx context.Context, testID uint, questionID uint) error
 	UpdateUserTest(ctx context.Context, userTest *domain.UserTests) error
-	CreateUserTest(ctx context.Context, userTest *domain.UserTests) error
+	BeginAttempt(ctx context.Context, test *domain.Test, userID uint, questionIDs []uint) (*domain.Attempt, error)
+	GetOpenAttempt(ctx context.Context, testID, userID uint) (*domain.Attempt, error)
+	GetAttempt(ctx context.Context, id uint) (*domain.Attempt, error)
+	SaveDraft(ctx context.Context, draft *domain.AttemptDraft) error
+	GetDrafts(ctx context.Context, attemptID uint) ([]*domain.AttemptDraft, error)
+	GetAttempts(ctx context.Context, testID, userID uint) ([]*domain.Attempt, error)
+	FinishAttempt(ctx context.Context, attempt *domain.Attempt, policy domain.ScoringPolicy) (*domain.UserTests, error)
+	GetExpiredAttempts(ctx context.Context, before time.Time, limit int) ([]*domain.Attempt, error)
+	UpdateAttemptExpiry(ctx context.Context, attempt *domain.Attempt) error
+	GetOpenAttemptsByTest(ctx context.Context, testID uint) ([]*domain.Attempt, error)
+	GetOverdueTestIDs(ctx context.Context, now time.Time) ([]uint, error)
+	GetDueDraftIDs(ctx context.Context, now time.Time) ([]uint, error)
+	ChangeStatus(ctx context.Context, change *domain.TestStatusChange) (bool, error)
+	GetStatusHistory(ctx context.Context, testID uint) ([]*domain.TestStatusChange, error)
+	GetGroupDeadlines(ctx context.Context, testIDs []uint) (map[uint]time.Time, error)
+	GetExtensionDeadlines(ctx context.Context, testIDs []uint) (map[uint]time.Time, error)
+	GetExtension(ctx context.Context, testID, userID uint) (*domain.TestExtension, error)
+	GetExtensions(ctx context.Context, testID uint) ([]*domain.TestExtension, error)
+	SetExtension(ctx context.Context, extension *domain.TestExtension, attempt *domain.Attempt) error
+	DeleteExtension(ctx context.Context, testID, userID uint) error
+	SetPools(ctx context.Context, testID uint, pools []*domain.QuestionPool) error
+	GetPoolCandidates(ctx context.Context, pool *domain.QuestionPool) ([]uint, error)
+	GetQuestionPoints(ctx context.Context, testID uint) (map[uint]float64, error)
+	GetAttemptQuestions(ctx context.Context, attemptID uint) ([]*domain.Question, error)
+	GetAttemptQuestionIDs(ctx context.Context, attemptID uint) ([]uint, error)
+	AddAttemptQuestion(ctx context.Context, attemptID, questionID uint) error
+	SaveAbility(ctx context.Context, attempt *domain.Attempt) error
+	GetEnrolledStudents(ctx context.Context, testID uint) ([]*domain.User, error)
+	GetUserTestsByTest(ctx context.Context, testID uint) ([]*domain.UserTests, error)
+	GetAttemptsByTest(ctx context.Context, testID uint) ([]*domain.Attempt, error)
 	GetCourseIDByTestID(ctx context.Context, testID uint) (uint, error)
-	CreateWithUser(ctx context.Context, test *domain.Test, courseID uint, userID uint) error
+	CreateRecommended(ctx context.Context, test *domain.Test, recommendation *domain.RecommendedTest, questionIDs []uint) (bool, error)
+	GetRecommendation(ctx context.Context, testID uint) (*domain.RecommendedTest, error)
+	GetStaleRecommendations(ctx context.Context, now, completedBefore time.Time, limit int) ([]*domain.RecommendedTest, error)
+	DeleteRecommended(ctx context.Context, recommendation *domain.RecommendedTest) error
+	Duplicate(ctx context.Context, source, duplicate *domain.Test, courseID uint, copyQuestions bool) error
+	Import(ctx context.Context, pkg *domain.TestPackage, test *domain.Test, options domain.TestImportOptions) ([]domain.ImportedQuestion, error)
+	GetQuestionsByIDs(ctx context.Context, ids []uint) ([]*domain.Question, error)
+	GetGroupOverrides(ctx context.Context, testID, userID uint) ([]*domain.GroupTest, error)
+	GetStatus(ctx context.Context, testID uint) (domain.TestStatus, error)
+	IsUserEnrolled(ctx context.Context, testID, userID uint) (bool, error)
+	SaveAnswer(ctx context.Context, answer *domain.UserTestAnswer) error
+	GetAnswers(ctx context.Context, testID, userID uint) ([]*domain.UserTestAnswer, error)
 }
 
 func NewTestRepository(db *gorm.DB) ITestRepository { return &testRepository{db: db} }
@@ -54,20 +97,53 @@ func (r *testRepository) GetByID(ctx context.Context, id, userID uint) (*domain.
 	var test domain.Test
 
 	err := r.db.
-		Preload("Questions", "deleted_at IS NULL").
+		Preload("Questions", func(db *gorm.DB) *gorm.DB {
+			return db.
+				Joins("JOIN test_questions ON test_questions.question_id = questions.id AND test_questions.test_id = ?", id).
+				Order("test_questions.position, test_questions.question_id")
+		}).
+		Preload("Pools").
 		Preload("UserTests", "user_id = ?", userID). // ← фильтрация по userID
 		Where("id = ?", id).
 		First(&test).Error
 
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTestNotFound
+		}
+		return nil, err
+	}
+
+	points, err := r.GetQuestionPoints(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	for _, question := range test.Questions {
+		question.Points = points[question.ID]
+	}
 
 	return &test, nil
 }
 
-func (r *testRepository) Update(ctx context.Context, test *domain.Test) error {
-	updates := validator.BuildUpdates(test)
+// GetQuestionPoints возвращает веса вопросов теста по их ID.
+func (r *testRepository) GetQuestionPoints(ctx context.Context, testID uint) (map[uint]float64, error) {
+	var links []*domain.TestQuestion
+	if err := r.db.Where("test_id = ?", testID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	points := make(map[uint]float64, len(links))
+	for _, link := range links {
+		points[link.QuestionID] = link.Points
+	}
+
+	return points, nil
+}
+
+// Update меняет заданные поля теста, а поля reset сбрасывает в нулевое
+// значение.
+func (r *testRepository) Update(ctx context.Context, test *domain.Test, reset ...string) error {
+	updates := validator.BuildUpdates(test, reset...)
 
 	result := r.db.Model(&domain.Test{}).Where("id = ?", test.ID).Updates(updates).First(&test)
 	if result.Error != nil {
@@ -95,74 +171,681 @@ func (r *testRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.Delete(&test).Error
 }
 
-func (r *testRepository) AttachQuestion(ctx context.Context, testID uint, questionID uint) error {
-	err := r.db.Model(&domain.Test{ID: testID}).Association("Questions").Append(&domain.Question{ID: questionID})
-	if err != nil {
+// AttachQuestion прикрепляет вопрос к тесту на позицию index или, при
+// index == nil, в конец списка. Положительный points задаёт вес вопроса,
+// иначе вес уже прикреплённого вопроса сохраняется, а новый стоит один балл.
+func (r *testRepository) AttachQuestion(ctx context.Context, testID uint, questionID uint, index *int, points float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		links, err := lockQuestionLinks(tx, testID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&domain.Question{}).Where("id = ?", questionID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return domain.ErrQuestionNotFound
+		}
+
+		weights := questionWeights(links)
+		if points > 0 {
+			weights[questionID] = points
+		}
+
+		return writeQuestionLinks(tx, testID, domain.InsertQuestion(questionOrder(links), questionID, index), weights)
+	})
+}
+
+// ReorderQuestions задаёт порядок вопросов теста. questionIDs должен
+// содержать каждый прикреплённый вопрос ровно один раз.
+func (r *testRepository) ReorderQuestions(ctx context.Context, testID uint, questionIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		links, err := lockQuestionLinks(tx, testID)
+		if err != nil {
+			return err
+		}
+
+		if err := domain.ValidateQuestionOrder(questionOrder(links), questionIDs); err != nil {
+			return err
+		}
+
+		return writeQuestionLinks(tx, testID, questionIDs, questionWeights(links))
+	})
+}
+
+// ApplyQuestionBatch в одной транзакции прикрепляет, открепляет или
+// заменяет вопросы теста. Все прикрепляемые вопросы должны существовать.
+func (r *testRepository) ApplyQuestionBatch(ctx context.Context, testID uint, batch *domain.QuestionBatch) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		links, err := lockQuestionLinks(tx, testID)
+		if err != nil {
+			return err
+		}
+
+		ids := batch.AttachIDs()
+		if len(ids) > 0 {
+			var found []uint
+			if err := tx.Model(&domain.Question{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+				return err
+			}
+			if missing := missingIDs(ids, found); len(missing) > 0 {
+				return fmt.Errorf("%w: %v", domain.ErrQuestionNotFound, missing)
+			}
+		}
+
+		order, weights := batch.Apply(questionOrder(links), questionWeights(links))
+		return writeQuestionLinks(tx, testID, order, weights)
+	})
+}
+
+func missingIDs(ids, found []uint) []uint {
+	exists := make(map[uint]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+
+	var missing []uint
+	for _, id := range ids {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// lockQuestionLinks блокирует строку теста до конца транзакции, чтобы
+// параллельные изменения списка вопросов не перемешались, и возвращает
+// связи с вопросами в текущем порядке.
+func lockQuestionLinks(tx *gorm.DB, testID uint) ([]*domain.TestQuestion, error) {
+	var test domain.Test
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&test, testID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTestNotFound
+		}
+		return nil, err
+	}
+
+	var links []*domain.TestQuestion
+	err := tx.Where("test_id = ?", testID).Order("position, question_id").Find(&links).Error
+
+	return links, err
+}
+
+// writeQuestionLinks перезаписывает список вопросов теста в порядке order
+// с весами weights. Вопросы без веса стоят один балл.
+func writeQuestionLinks(tx *gorm.DB, testID uint, order []uint, weights map[uint]float64) error {
+	if err := tx.Where("test_id = ?", testID).Delete(&domain.TestQuestion{}).Error; err != nil {
 		return err
 	}
+	if len(order) == 0 {
+		return nil
+	}
 
-	return nil
+	links := make([]*domain.TestQuestion, len(order))
+	for i, questionID := range order {
+		points, ok := weights[questionID]
+		if !ok {
+			points = 1
+		}
+		links[i] = &domain.TestQuestion{TestID: testID, QuestionID: questionID, Position: uint(i), Points: points}
+	}
+
+	return tx.Create(&links).Error
+}
+
+func questionOrder(links []*domain.TestQuestion) []uint {
+	order := make([]uint, len(links))
+	for i, link := range links {
+		order[i] = link.QuestionID
+	}
+	return order
+}
+
+func questionWeights(links []*domain.TestQuestion) map[uint]float64 {
+	weights := make(map[uint]float64, len(links))
+	for _, link := range links {
+		weights[link.QuestionID] = link.Points
+	}
+	return weights
 }
 
+// DetachQuestion открепляет вопрос от теста. Позиции оставшихся вопросов
+// перенумеровываются, как и при остальных изменениях списка.
 func (r *testRepository) DetachQuestion(ctx context.Context, testID uint, questionID uint) error {
+	return r.ApplyQuestionBatch(ctx, testID, &domain.QuestionBatch{Detach: []uint{questionID}})
+}
+
+// BeginAttempt начинает новую попытку студента. Если незавершённая попытка
+// уже есть, возвращает её, чтобы повторный вызов не тратил попытки.
+// Параллельные вызовы для одной пары тест-студент сериализуются
+// advisory-блокировкой транзакции. questionIDs - вопросы, выданные в новой
+// попытке теста с пулами; для теста без пулов nil.
+func (r *testRepository) BeginAttempt(ctx context.Context, test *domain.Test, userID uint, questionIDs []uint) (*domain.Attempt, error) {
+	var attempt domain.Attempt
+	testID := test.ID
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", int32(testID), int32(userID)).Error; err != nil {
+			return err
+		}
+
+		err := tx.
+			Where("test_id = ? AND user_id = ? AND status = ?", testID, userID, domain.InProgress).
+			First(&attempt).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&domain.Attempt{}).
+			Where("test_id = ? AND user_id = ?", testID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if test.MaxAttempts > 0 && uint(count) >= test.MaxAttempts {
+			return domain.ErrNoAttemptsLeft
+		}
+
+		now := time.Now()
+		attempt = domain.Attempt{
+			TestID:    testID,
+			UserID:    userID,
+			Number:    uint(count) + 1,
+			Status:    domain.InProgress,
+			StartedAt: now,
+			ExpiresAt: test.AttemptExpiry(now),
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+
+		if len(questionIDs) > 0 {
+			questions := make([]*domain.AttemptQuestion, len(questionIDs))
+			for i, questionID := range questionIDs {
+				questions[i] = &domain.AttemptQuestion{
+					AttemptID:  attempt.ID,
+					QuestionID: questionID,
+					Position:   uint(i),
+				}
+			}
+			if err := tx.Create(&questions).Error; err != nil {
+				return err
+			}
+		}
+
+		// новая попытка начинается с пустого листа ответов
+		if err := tx.
+			Where("test_id = ? AND user_id = ?", testID, userID).
+			Delete(&domain.UserTestAnswer{}).Error; err != nil {
+			return err
+		}
+
+		// итоговый результат по прошлым попыткам сохраняется
+		return tx.
+			Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "test_id"}, {Name: "user_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"status":  domain.InProgress,
+					"attempt": attempt.Number,
+				}),
+			}).
+			Create(&domain.UserTests{
+				TestID:  testID,
+				UserID:  userID,
+				Status:  domain.InProgress,
+				Attempt: attempt.Number,
+			}).
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r *testRepository) GetOpenAttempt(ctx context.Context, testID, userID uint) (*domain.Attempt, error) {
+	var attempt domain.Attempt
+
 	err := r.db.
-		Model(&domain.Test{ID: testID}).
-		Association("Questions").
-		Delete(&domain.Question{ID: questionID})
+		Where("test_id = ? AND user_id = ? AND status = ?", testID, userID, domain.InProgress).
+		First(&attempt).Error
 	if err != nil {
-		return err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTestNotStarted
+		}
+		return nil, err
 	}
-	return nil
+
+	return &attempt, nil
 }
 
-func (r *testRepository) CreateUserTest(ctx context.Context, ut *domain.UserTests) error {
-	// Убедимся, что ut.Status == domain.InProgress, Progress == 0
-	ut.Status = domain.InProgress
-	ut.Progress = 0
+func (r *testRepository) GetAttempts(ctx context.Context, testID, userID uint) ([]*domain.Attempt, error) {
+	var attempts []*domain.Attempt
 
+	err := r.db.
+		Where("test_id = ? AND user_id = ?", testID, userID).
+		Order("number").
+		Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func (r *testRepository) GetAttempt(ctx context.Context, id uint) (*domain.Attempt, error) {
+	var attempt domain.Attempt
+
+	err := r.db.Where("id = ?", id).First(&attempt).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAttemptNotFound
+		}
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// SaveDraft сохраняет черновик ответа, перезаписывая прежний.
+func (r *testRepository) SaveDraft(ctx context.Context, draft *domain.AttemptDraft) error {
 	return r.db.
 		Clauses(clause.OnConflict{
-			Columns: []clause.Column{{Name: "test_id"}, {Name: "user_id"}},
-			DoUpdates: clause.Assignments(map[string]interface{}{
-				"status":   ut.Status,
-				"progress": ut.Progress,
-			}),
+			Columns:   []clause.Column{{Name: "attempt_id"}, {Name: "question_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"answer", "updated_at"}),
 		}).
-		Create(ut).
-		Error
+		Create(draft).Error
+}
+
+func (r *testRepository) GetDrafts(ctx context.Context, attemptID uint) ([]*domain.AttemptDraft, error) {
+	var drafts []*domain.AttemptDraft
+
+	err := r.db.Where("attempt_id = ?", attemptID).Order("question_id").Find(&drafts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return drafts, nil
+}
+
+// FinishAttempt закрывает попытку с результатом attempt.Score и баллами
+// attempt.Points из attempt.MaxPoints и пересчитывает
+// итоговый результат студента по тесту согласно policy.
+func (r *testRepository) FinishAttempt(ctx context.Context, attempt *domain.Attempt, policy domain.ScoringPolicy) (*domain.UserTests, error) {
+	var userTest domain.UserTests
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		attempt.Status = domain.Completed
+		attempt.FinishedAt = &now
+
+		result := tx.Model(&domain.Attempt{}).
+			Where("id = ? AND status = ?", attempt.ID, domain.InProgress).
+			Updates(map[string]interface{}{
+				"status":       attempt.Status,
+				"score":        attempt.Score,
+				"points":       attempt.Points,
+				"max_points":   attempt.MaxPoints,
+				"late":         attempt.Late,
+				"late_penalty": attempt.LatePenalty,
+				"ability":      attempt.Ability,
+				"ability_se":   attempt.AbilitySE,
+				"finished_at":  attempt.FinishedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrTestNotStarted
+		}
+
+		var attempts []*domain.Attempt
+		if err := tx.
+			Where("test_id = ? AND user_id = ?", attempt.TestID, attempt.UserID).
+			Order("number").
+			Find(&attempts).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.UserTests{}).
+			Where("test_id = ? AND user_id = ?", attempt.TestID, attempt.UserID).
+			Updates(map[string]interface{}{
+				"status":   domain.Completed,
+				"progress": policy.Aggregate(attempts),
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.
+			Where("test_id = ? AND user_id = ?", attempt.TestID, attempt.UserID).
+			First(&userTest).Error; err != nil {
+			return err
+		}
+		userTest.Attempts = attempts
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &userTest, nil
 }
 
-// CreateWithUser - создаём тест, цепляем к курсу и сразу добавляем
-// запись в user_tests.
-func (r *testRepository) CreateWithUser(
+// CreateRecommended в одной транзакции регистрирует рекомендацию и создаёт
+// по ней тест с вопросами questionIDs и записью студента в user_tests.
+// Если рекомендация с тем же ключом уже есть, recommendation заполняется
+// ею, тест не создаётся и возвращается false.
+func (r *testRepository) CreateRecommended(
 	ctx context.Context,
 	test *domain.Test,
-	courseID uint,
-	userID uint,
-) error {
+	recommendation *domain.RecommendedTest,
+	questionIDs []uint,
+) (bool, error) {
+	created := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(recommendation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.
+				Where("user_id = ? AND course_id = ? AND recommendation_id = ?",
+					recommendation.UserID, recommendation.CourseID, recommendation.RecommendationID).
+				First(recommendation).Error
+		}
+
+		test.Name = recommendation.TestName(test.Name)
+		if err := createCourseTest(tx, test, recommendation.CourseID); err != nil {
+			return err
+		}
+		userTest := &domain.UserTests{UserID: recommendation.UserID, TestID: test.ID, Status: domain.New}
+		if err := tx.Create(userTest).Error; err != nil {
+			return err
+		}
+
+		order := domain.UniqueIDs(questionIDs)
+		if len(order) > 0 {
+			var found []uint
+			if err := tx.Model(&domain.Question{}).Where("id IN ?", order).Pluck("id", &found).Error; err != nil {
+				return err
+			}
+			if missing := missingIDs(order, found); len(missing) > 0 {
+				return fmt.Errorf("%w: %v", domain.ErrQuestionNotFound, missing)
+			}
+		}
+		if err := writeQuestionLinks(tx, test.ID, order, nil); err != nil {
+			return err
+		}
+
+		recommendation.TestID = test.ID
+		if err := tx.Model(recommendation).Update("test_id", test.ID).Error; err != nil {
+			return err
+		}
+
+		created = true
+		return nil
+	})
+
+	return created, err
+}
+
+// GetRecommendation возвращает рекомендацию, по которой создан тест, или
+// nil, если тест создан не рекомендательной системой.
+func (r *testRepository) GetRecommendation(ctx context.Context, testID uint) (*domain.RecommendedTest, error) {
+	var recommendations []*domain.RecommendedTest
+	if err := r.db.Where("test_id = ?", testID).Limit(1).Find(&recommendations).Error; err != nil {
+		return nil, err
+	}
+	if len(recommendations) == 0 {
+		return nil, nil
+	}
+
+	return recommendations[0], nil
+}
+
+// GetStaleRecommendations возвращает рекомендации, истёкшие к now, и те,
+// тест которых студент завершил и последнюю попытку сдал до completedBefore.
+func (r *testRepository) GetStaleRecommendations(ctx context.Context, now, completedBefore time.Time, limit int) ([]*domain.RecommendedTest, error) {
+	var recommendations []*domain.RecommendedTest
+
+	err := r.db.
+		Where(`expires_at <= ? OR EXISTS (
+			SELECT 1 FROM user_tests
+			WHERE user_tests.test_id = recommended_tests.test_id
+				AND user_tests.user_id = recommended_tests.user_id
+				AND user_tests.status = ?
+				AND NOT EXISTS (
+					SELECT 1 FROM attempts
+					WHERE attempts.test_id = recommended_tests.test_id
+						AND attempts.user_id = recommended_tests.user_id
+						AND (attempts.finished_at IS NULL OR attempts.finished_at > ?)
+				)
+		)`, now, domain.Completed, completedBefore).
+		Order("id").
+		Limit(limit).
+		Find(&recommendations).Error
+
+	return recommendations, err
+}
+
+// DeleteRecommended удаляет тест рекомендации из курса и саму рекомендацию.
+func (r *testRepository) DeleteRecommended(ctx context.Context, recommendation *domain.RecommendedTest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if recommendation.TestID > 0 {
+			if err := tx.Where("test_id = ?", recommendation.TestID).Delete(&domain.CourseTest{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&domain.Test{}, recommendation.TestID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(recommendation).Error
+	})
+}
 
+// Duplicate в одной транзакции создаёт тест duplicate в курсе courseID и
+// переносит в него вопросы и пулы теста source. При copyQuestions вопросы
+// копируются, иначе новый тест ссылается на те же вопросы.
+func (r *testRepository) Duplicate(ctx context.Context, source, duplicate *domain.Test, courseID uint, copyQuestions bool) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 1. сам тест
-		if err := tx.Create(test).Error; err != nil {
+		if err := createCourseTest(tx, duplicate, courseID); err != nil {
 			return err
 		}
-		// 2. связь «курс-тест»
-		if err := tx.Model(test).
-			Association("Courses").
-			Append(&domain.Course{ID: courseID}); err != nil {
+
+		links := make([]*domain.TestQuestion, 0, len(source.Questions))
+		for i, question := range source.Questions {
+			questionID := question.ID
+			if copyQuestions {
+				copied := question.Copy()
+				if err := tx.Omit(clause.Associations).Create(copied).Error; err != nil {
+					return err
+				}
+				questionID = copied.ID
+			}
+			links = append(links, &domain.TestQuestion{
+				TestID:     duplicate.ID,
+				QuestionID: questionID,
+				Position:   uint(i),
+				Points:     question.MaxScore(),
+			})
+		}
+		if len(links) > 0 {
+			if err := tx.Create(&links).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(source.Pools) > 0 {
+			pools := make([]*domain.QuestionPool, len(source.Pools))
+			for i, pool := range source.Pools {
+				pools[i] = pool.Copy()
+				pools[i].TestID = duplicate.ID
+			}
+			if err := tx.Create(&pools).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// createCourseTest создаёт тест без связей и добавляет его в курс courseID.
+// Имя теста должно быть свободно, включая удалённые тесты.
+func createCourseTest(tx *gorm.DB, test *domain.Test, courseID uint) error {
+	var count int64
+	if err := tx.Model(&domain.Course{}).Where("id = ?", courseID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrCourseNotFound
+	}
+
+	if err := tx.Unscoped().Model(&domain.Test{}).Where("name = ?", test.Name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return domain.ErrTestNameTaken
+	}
+
+	if err := tx.Omit(clause.Associations).Create(test).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&domain.CourseTest{CourseID: courseID, TestID: test.ID}).Error
+}
+
+// Import в одной транзакции создаёт по пакету тест test в курсе
+// options.CourseID. Вопрос пакета берётся из options.Mapping, иначе
+// переиспользуется существующий вопрос с тем же содержимым или создаётся
+// новый. Повторно сопоставленный вопрос прикрепляется к тесту один раз.
+func (r *testRepository) Import(
+	ctx context.Context,
+	pkg *domain.TestPackage,
+	test *domain.Test,
+	options domain.TestImportOptions,
+) ([]domain.ImportedQuestion, error) {
+	imported := make([]domain.ImportedQuestion, 0, len(pkg.Questions))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := createCourseTest(tx, test, options.CourseID); err != nil {
 			return err
 		}
-		// 3. запись в user_tests
-		ut := &domain.UserTests{
-			UserID: userID,
-			TestID: test.ID,
-			Status: domain.New,
+
+		ids := make(map[string]uint, len(pkg.Questions))
+		order := make([]uint, 0, len(pkg.Questions))
+		weights := make(map[uint]float64, len(pkg.Questions))
+		attached := make(map[uint]bool, len(pkg.Questions))
+
+		for i := range pkg.Questions {
+			question := &pkg.Questions[i]
+
+			result, err := importQuestion(tx, question, options)
+			if err != nil {
+				return err
+			}
+			imported = append(imported, result)
+			ids[question.Key] = result.QuestionID
+
+			if question.PoolOnly || attached[result.QuestionID] {
+				continue
+			}
+			attached[result.QuestionID] = true
+			order = append(order, result.QuestionID)
+			if question.Points > 0 {
+				weights[result.QuestionID] = question.Points
+			}
 		}
-		if err := tx.Create(ut).Error; err != nil {
+
+		if err := writeQuestionLinks(tx, test.ID, order, weights); err != nil {
 			return err
 		}
+
+		if len(pkg.Pools) > 0 {
+			pools := make([]*domain.QuestionPool, len(pkg.Pools))
+			for i, pool := range pkg.Pools {
+				pools[i] = &domain.QuestionPool{
+					TestID:     test.ID,
+					Tag:        pool.Tag,
+					Count:      pool.Count,
+					Difficulty: pool.Difficulty,
+				}
+				for _, key := range pool.QuestionKeys {
+					pools[i].QuestionIDs = append(pools[i].QuestionIDs, ids[key])
+				}
+			}
+			if err := tx.Create(&pools).Error; err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+// importQuestion сопоставляет вопрос пакета существующему или создаёт его.
+func importQuestion(tx *gorm.DB, question *domain.PackageQuestion, options domain.TestImportOptions) (domain.ImportedQuestion, error) {
+	if id, ok := options.Mapping[question.Key]; ok {
+		var count int64
+		if err := tx.Model(&domain.Question{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return domain.ImportedQuestion{}, err
+		}
+		if count == 0 {
+			return domain.ImportedQuestion{}, fmt.Errorf("%w: %d", domain.ErrQuestionNotFound, id)
+		}
+
+		return domain.ImportedQuestion{
+			Key:        question.Key,
+			Title:      question.Title,
+			QuestionID: id,
+			Status:     domain.ImportMapped,
+		}, nil
+	}
+
+	var candidates []*domain.Question
+	err := tx.Where("title = ? AND type = ?", question.Title, question.Type).Order("id").Find(&candidates).Error
+	if err != nil {
+		return domain.ImportedQuestion{}, err
+	}
+
+	existing, result := domain.ResolveImportedQuestion(question, candidates, options.OnConflict)
+	if existing != nil {
+		return result, nil
+	}
+
+	created := question.Question()
+	if err := tx.Omit(clause.Associations).Create(created).Error; err != nil {
+		return domain.ImportedQuestion{}, err
+	}
+	result.QuestionID = created.ID
+
+	return result, nil
+}
+
+// GetQuestionsByIDs возвращает вопросы в порядке ID.
+func (r *testRepository) GetQuestionsByIDs(ctx context.Context, ids []uint) ([]*domain.Question, error) {
+	var questions []*domain.Question
+	if len(ids) == 0 {
+		return questions, nil
+	}
+
+	err := r.db.Where("id IN ?", ids).Order("id").Find(&questions).Error
+
+	return questions, err
 }
 
 func (r *testRepository) UpdateUserTest(ctx context.Context, userTest *domain.UserTests) error {
@@ -193,3 +876,436 @@ func (r *testRepository) GetCourseIDByTestID(ctx context.Context, testID uint) (
 	}
 	return tst.Courses[0].ID, nil
 }
+
+// GetGroupOverrides возвращает переопределения теста для всех групп,
+// в которых состоит пользователь.
+func (r *testRepository) GetGroupOverrides(ctx context.Context, testID, userID uint) ([]*domain.GroupTest, error) {
+	var overrides []*domain.GroupTest
+
+	err := r.db.
+		Joins("JOIN group_users ON group_users.group_id = group_tests.group_id").
+		Joins("JOIN groups ON groups.id = group_tests.group_id AND groups.deleted_at IS NULL").
+		Where("group_tests.test_id = ? AND group_users.user_id = ?", testID, userID).
+		Find(&overrides).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+func (r *testRepository) GetStatus(ctx context.Context, testID uint) (domain.TestStatus, error) {
+	var test domain.Test
+
+	err := r.db.Select("id", "status").First(&test, testID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", domain.ErrTestNotFound
+		}
+		return "", err
+	}
+
+	return test.Status, nil
+}
+
+// IsUserEnrolled - записан ли пользователь хотя бы на один курс, в который
+// входит тест.
+func (r *testRepository) IsUserEnrolled(ctx context.Context, testID, userID uint) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&domain.CourseTest{}).
+		Joins("JOIN user_courses ON user_courses.course_id = course_tests.course_id").
+		Where("course_tests.test_id = ? AND user_courses.user_id = ?", testID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// SaveAnswer заносит ответ в лист ответов попытки. Засчитывается первый
+// ответ на вопрос: повторная проверка лист не меняет, иначе можно было бы
+// исправить ответ, узнав правильный.
+func (r *testRepository) SaveAnswer(ctx context.Context, answer *domain.UserTestAnswer) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "test_id"}, {Name: "user_id"}, {Name: "question_id"}},
+			DoNothing: true,
+		}).
+		Create(answer).Error
+}
+
+func (r *testRepository) GetAnswers(ctx context.Context, testID, userID uint) ([]*domain.UserTestAnswer, error) {
+	var answers []*domain.UserTestAnswer
+
+	err := r.db.Where("test_id = ? AND user_id = ?", testID, userID).Find(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
+
+// GetExpiredAttempts возвращает незавершённые попытки, время которых
+// истекло раньше before.
+func (r *testRepository) GetExpiredAttempts(ctx context.Context, before time.Time, limit int) ([]*domain.Attempt, error) {
+	var attempts []*domain.Attempt
+
+	err := r.db.
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", domain.InProgress, before).
+		Order("expires_at").
+		Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// UpdateAttemptExpiry сохраняет пересчитанный срок незавершённой попытки.
+func (r *testRepository) UpdateAttemptExpiry(ctx context.Context, attempt *domain.Attempt) error {
+	return r.db.Model(&domain.Attempt{}).
+		Where("id = ? AND status = ?", attempt.ID, domain.InProgress).
+		Update("expires_at", attempt.ExpiresAt).Error
+}
+
+func (r *testRepository) GetOpenAttemptsByTest(ctx context.Context, testID uint) ([]*domain.Attempt, error) {
+	var attempts []*domain.Attempt
+
+	err := r.db.
+		Where("test_id = ? AND status = ?", testID, domain.InProgress).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// GetOverdueTestIDs возвращает запущенные тесты, дедлайн которых истёк
+// к моменту now для всех студентов, включая группы с продлённым дедлайном.
+func (r *testRepository) GetOverdueTestIDs(ctx context.Context, now time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.
+		Model(&domain.Test{}).
+		Where("status = ? AND deadline > ?", domain.Progress, time.Time{}).
+		Where("GREATEST(deadline, COALESCE((SELECT MAX(group_tests.deadline) FROM group_tests WHERE group_tests.test_id = tests.id), deadline)) + late_hours * INTERVAL '1 hour' < ?", now).
+		Where("COALESCE((SELECT MAX(test_extensions.deadline) FROM test_extensions WHERE test_extensions.test_id = tests.id), deadline) < ?", now).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetDueDraftIDs возвращает черновики, время публикации которых наступило.
+func (r *testRepository) GetDueDraftIDs(ctx context.Context, now time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.
+		Model(&domain.Test{}).
+		Where("status = ? AND opens_at IS NOT NULL AND opens_at <= ?", domain.Draft, now).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ChangeStatus переводит тест из change.From в change.To и пишет запись в
+// историю статусов. Возвращает false, если статус теста уже не change.From,
+// например его успел сменить преподаватель или другая реплика.
+func (r *testRepository) ChangeStatus(ctx context.Context, change *domain.TestStatusChange) (bool, error) {
+	var changed bool
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&domain.Test{}).
+			Where("id = ? AND status = ?", change.TestID, change.From).
+			Update("status", change.To)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		changed = true
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return changed, nil
+}
+
+func (r *testRepository) GetStatusHistory(ctx context.Context, testID uint) ([]*domain.TestStatusChange, error) {
+	var changes []*domain.TestStatusChange
+
+	err := r.db.
+		Where("test_id = ?", testID).
+		Order("created_at, id").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetGroupDeadlines возвращает самый поздний дедлайн групп по каждому тесту.
+func (r *testRepository) GetGroupDeadlines(ctx context.Context, testIDs []uint) (map[uint]time.Time, error) {
+	var rows []struct {
+		TestID   uint
+		Deadline time.Time
+	}
+
+	err := r.db.
+		Model(&domain.GroupTest{}).
+		Select("test_id, MAX(deadline) AS deadline").
+		Where("test_id IN ? AND deadline IS NOT NULL", testIDs).
+		Group("test_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		deadlines[row.TestID] = row.Deadline
+	}
+
+	return deadlines, nil
+}
+
+// GetExtensionDeadlines возвращает самый поздний индивидуальный дедлайн
+// по каждому тесту, у которого есть продления.
+func (r *testRepository) GetExtensionDeadlines(ctx context.Context, testIDs []uint) (map[uint]time.Time, error) {
+	var rows []struct {
+		TestID   uint
+		Deadline time.Time
+	}
+
+	err := r.db.
+		Model(&domain.TestExtension{}).
+		Select("test_id, MAX(deadline) AS deadline").
+		Where("test_id IN ?", testIDs).
+		Group("test_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		deadlines[row.TestID] = row.Deadline
+	}
+
+	return deadlines, nil
+}
+
+func (r *testRepository) GetExtension(ctx context.Context, testID, userID uint) (*domain.TestExtension, error) {
+	var extension domain.TestExtension
+
+	err := r.db.Where("test_id = ? AND user_id = ?", testID, userID).First(&extension).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrExtensionNotFound
+		}
+		return nil, err
+	}
+
+	return &extension, nil
+}
+
+func (r *testRepository) GetExtensions(ctx context.Context, testID uint) ([]*domain.TestExtension, error) {
+	var extensions []*domain.TestExtension
+
+	err := r.db.Where("test_id = ?", testID).Order("user_id").Find(&extensions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return extensions, nil
+}
+
+// SetExtension создаёт или заменяет продление дедлайна студента.
+// SetExtension сохраняет продление и в той же транзакции переносит срок
+// открытой попытки студента attempt, если она есть.
+func (r *testRepository) SetExtension(ctx context.Context, extension *domain.TestExtension, attempt *domain.Attempt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "test_id"}, {Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"deadline", "granted_by", "updated_at"}),
+			}).
+			Create(extension).Error
+		if err != nil || attempt == nil {
+			return err
+		}
+
+		return tx.Model(&domain.Attempt{}).
+			Where("id = ? AND status = ?", attempt.ID, domain.InProgress).
+			Update("expires_at", attempt.ExpiresAt).Error
+	})
+}
+
+func (r *testRepository) DeleteExtension(ctx context.Context, testID, userID uint) error {
+	result := r.db.Where("test_id = ? AND user_id = ?", testID, userID).Delete(&domain.TestExtension{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrExtensionNotFound
+	}
+
+	return nil
+}
+
+func (r *testRepository) SetPools(ctx context.Context, testID uint, pools []*domain.QuestionPool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("test_id = ?", testID).Delete(&domain.QuestionPool{}).Error; err != nil {
+			return err
+		}
+		if len(pools) == 0 {
+			return nil
+		}
+		for _, pool := range pools {
+			pool.TestID = testID
+		}
+		return tx.Create(&pools).Error
+	})
+}
+
+// GetPoolCandidates возвращает ID вопросов, подходящих под правило пула.
+// Вопросы, прикреплённые к тесту напрямую, в пул не входят.
+func (r *testRepository) GetPoolCandidates(ctx context.Context, pool *domain.QuestionPool) ([]uint, error) {
+	var ids []uint
+
+	query := r.db.
+		Model(&domain.Question{}).
+		Where("id NOT IN (SELECT question_id FROM test_questions WHERE test_id = ?)", pool.TestID)
+	if pool.Tag != "" {
+		query = query.Where("tag = ?", pool.Tag)
+	}
+	if len(pool.QuestionIDs) > 0 {
+		query = query.Where("id IN ?", []uint(pool.QuestionIDs))
+	}
+	if pool.Difficulty != nil {
+		query = query.Where("difficulty = ?", *pool.Difficulty)
+	}
+
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetAttemptQuestions возвращает вопросы, выданные в попытке, в порядке
+// выдачи. Для попытки теста без пулов список пуст.
+func (r *testRepository) GetAttemptQuestions(ctx context.Context, attemptID uint) ([]*domain.Question, error) {
+	var questions []*domain.Question
+
+	err := r.db.
+		Joins("JOIN attempt_questions ON attempt_questions.question_id = questions.id").
+		Where("attempt_questions.attempt_id = ?", attemptID).
+		Order("attempt_questions.position").
+		Find(&questions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+func (r *testRepository) GetAttemptQuestionIDs(ctx context.Context, attemptID uint) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.
+		Model(&domain.AttemptQuestion{}).
+		Where("attempt_id = ?", attemptID).
+		Order("position").
+		Pluck("question_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// AddAttemptQuestion выдаёт в попытке ещё один вопрос следующим по порядку.
+func (r *testRepository) AddAttemptQuestion(ctx context.Context, attemptID, questionID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.AttemptQuestion{}).Where("attempt_id = ?", attemptID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&domain.AttemptQuestion{
+			AttemptID:  attemptID,
+			QuestionID: questionID,
+			Position:   uint(count),
+		}).Error
+	})
+}
+
+// SaveAbility сохраняет текущую оценку способности открытой попытки.
+func (r *testRepository) SaveAbility(ctx context.Context, attempt *domain.Attempt) error {
+	return r.db.Model(&domain.Attempt{}).
+		Where("id = ? AND status = ?", attempt.ID, domain.InProgress).
+		Updates(map[string]interface{}{
+			"ability":    attempt.Ability,
+			"ability_se": attempt.AbilitySE,
+		}).Error
+}
+
+// GetEnrolledStudents возвращает студентов, записанных на курсы теста.
+func (r *testRepository) GetEnrolledStudents(ctx context.Context, testID uint) ([]*domain.User, error) {
+	var users []*domain.User
+
+	err := r.db.
+		Where("role = ?", domain.RoleStudent).
+		Where("id IN (?)", r.db.
+			Table("user_courses").
+			Select("user_courses.user_id").
+			Joins("JOIN course_tests ON course_tests.course_id = user_courses.course_id").
+			Where("course_tests.test_id = ?", testID)).
+		Order("last_name, first_name, id").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *testRepository) GetUserTestsByTest(ctx context.Context, testID uint) ([]*domain.UserTests, error) {
+	var userTests []*domain.UserTests
+
+	if err := r.db.Where("test_id = ?", testID).Find(&userTests).Error; err != nil {
+		return nil, err
+	}
+
+	return userTests, nil
+}
+
+func (r *testRepository) GetAttemptsByTest(ctx context.Context, testID uint) ([]*domain.Attempt, error) {
+	var attempts []*domain.Attempt
+
+	err := r.db.
+		Where("test_id = ?", testID).
+		Order("user_id, number").
+		Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"diprec_api/internal/domain"
+	"diprec_api/internal/repository/course"
+	"diprec_api/internal/repository/test"
+)
+
+// AccessPolicy решает, может ли пользователь читать курс или тест и
+// приступать к тесту. Преподаватель, записанный на курс, считается его
+// персоналом и проходит проверку без ограничений, но только в этом курсе.
+// Тест рекомендации доступен только студенту, для которого он создан.
+//
+// Семантика ошибок единая: несуществующий объект и черновик теста для
+// студента - ErrCourseNotFound/ErrTestNotFound (404), отсутствие записи на
+// курс и закрытый для попыток тест - ErrNotEnrolled/ErrTestEnded (403).
+type AccessPolicy struct {
+	courses course.ICourseRepository
+	tests   test.ITestRepository
+}
+
+func NewAccessPolicy(courses course.ICourseRepository, tests test.ITestRepository) *AccessPolicy {
+	return &AccessPolicy{courses: courses, tests: tests}
+}
+
+// IsStaff - роль преподавателя. Права на конкретный курс или тест она не
+// даёт: их проверяет AccessPolicy по записи на курс.
+func IsStaff(role string) bool {
+	return role == domain.RoleTeacher.String()
+}
+
+// CanViewCourse пускает к курсу записанных на него студентов и
+// преподавателей.
+func (p *AccessPolicy) CanViewCourse(ctx context.Context, courseID, userID uint, role string) error {
+	exists, err := p.courses.Exists(ctx, courseID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrCourseNotFound
+	}
+
+	enrolled, err := p.courses.IsEnrolled(ctx, courseID, userID)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return domain.ErrNotEnrolled
+	}
+
+	return nil
+}
+
+func (p *AccessPolicy) CanViewTest(ctx context.Context, testID, userID uint, role string) error {
+	_, err := p.viewableTestStatus(ctx, testID, userID, role)
+	return err
+}
+
+func (p *AccessPolicy) CanAttemptTest(ctx context.Context, testID, userID uint, role string) error {
+	status, err := p.viewableTestStatus(ctx, testID, userID, role)
+	if err != nil {
+		return err
+	}
+	staff, err := p.isTestStaff(ctx, testID, userID, role)
+	if err != nil {
+		return err
+	}
+	if staff {
+		return nil
+	}
+	if status != domain.Progress {
+		return domain.ErrTestEnded
+	}
+
+	return nil
+}
+
+func (p *AccessPolicy) viewableTestStatus(ctx context.Context, testID, userID uint, role string) (domain.TestStatus, error) {
+	status, err := p.tests.GetStatus(ctx, testID)
+	if err != nil {
+		return "", err
+	}
+	staff, err := p.isTestStaff(ctx, testID, userID, role)
+	if err != nil {
+		return "", err
+	}
+	if staff {
+		return status, nil
+	}
+	if status == domain.Draft {
+		return "", domain.ErrTestNotFound
+	}
+
+	// тест рекомендации виден только своему студенту и до истечения срока
+	recommendation, err := p.tests.GetRecommendation(ctx, testID)
+	if err != nil {
+		return "", err
+	}
+	if recommendation != nil && !recommendation.VisibleTo(userID, time.Now()) {
+		return "", domain.ErrTestNotFound
+	}
+
+	enrolled, err := p.tests.IsUserEnrolled(ctx, testID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !enrolled {
+		return "", domain.ErrNotEnrolled
+	}
+
+	return status, nil
+}
+
+// CanViewAttempt пускает к попытке её владельца, если тест ему всё ещё
+// виден, и персонал курса теста. Чужая попытка для остальных не существует.
+func (p *AccessPolicy) CanViewAttempt(ctx context.Context, attemptID, userID uint, role string) error {
+	attempt, err := p.tests.GetAttempt(ctx, attemptID)
+	if err != nil {
+		return err
+	}
+	if attempt.UserID == userID {
+		return p.CanViewTest(ctx, attempt.TestID, userID, role)
+	}
+
+	staff, err := p.isTestStaff(ctx, attempt.TestID, userID, role)
+	if err != nil {
+		return err
+	}
+	if !staff {
+		return domain.ErrAttemptNotFound
+	}
+
+	return nil
+}
+
+// isTestStaff - пользователь преподаватель и записан на курс теста.
+func (p *AccessPolicy) isTestStaff(ctx context.Context, testID, userID uint, role string) (bool, error) {
+	if !IsStaff(role) {
+		return false, nil
+	}
+	return p.tests.IsUserEnrolled(ctx, testID, userID)
+}
+
+// VisibleTests убирает из списка черновики, если пользователь не преподаватель.
+func VisibleTests(tests []*domain.Test, role string) []*domain.Test {
+	if IsStaff(role) {
+		return tests
+	}
+
+	visible := make([]*domain.Test, 0, len(tests))
+	for _, test := range tests {
+		if test.Status != domain.Draft {
+			visible = append(visible, test)
+		}
+	}
+	return visible
+}
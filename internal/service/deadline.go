@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"diprec_api/internal/domain"
+	"diprec_api/internal/repository/test"
+)
+
+// ApplyUserDeadline подставляет дедлайн и доступность теста с учётом групп
+// студента и его индивидуального продления.
+func ApplyUserDeadline(ctx context.Context, tests test.ITestRepository, t *domain.Test, userID uint) error {
+	overrides, err := tests.GetGroupOverrides(ctx, t.ID, userID)
+	if err != nil {
+		return err
+	}
+	t.ApplyGroupOverrides(overrides)
+
+	extension, err := tests.GetExtension(ctx, t.ID, userID)
+	if err != nil && !errors.Is(err, domain.ErrExtensionNotFound) {
+		return err
+	}
+	t.ApplyExtension(extension)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"diprec_api/internal/domain"
+	"diprec_api/internal/repository/stats"
+
+	"go.uber.org/zap"
+)
+
+// ItemAnalysisService периодически пересчитывает статистику вопросов по
+// истории ответов: в разрезе теста и по всем тестам сразу.
+type ItemAnalysisService struct {
+	repo   stats.IStatsRepository
+	logger *zap.Logger
+}
+
+func NewItemAnalysisService(repo stats.IStatsRepository, logger *zap.Logger) *ItemAnalysisService {
+	return &ItemAnalysisService{repo: repo, logger: logger.Named("ItemAnalysis")}
+}
+
+// Refresh пересчитывает статистику тестов, в которых появились новые
+// завершённые попытки, и общую статистику их вопросов. Возвращает число
+// обработанных тестов.
+func (s *ItemAnalysisService) Refresh(ctx context.Context) (int, error) {
+	testIDs, err := s.repo.GetStaleTestIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	touched := make(map[uint]bool)
+	for _, testID := range testIDs {
+		questionIDs, err := s.refreshScope(ctx, testID, nil, now)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range questionIDs {
+			touched[id] = true
+		}
+	}
+
+	if len(touched) > 0 {
+		questionIDs := make([]uint, 0, len(touched))
+		for id := range touched {
+			questionIDs = append(questionIDs, id)
+		}
+		if _, err := s.refreshScope(ctx, 0, questionIDs, now); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(testIDs), nil
+}
+
+// refreshScope пересчитывает статистику теста testID или, при testID = 0,
+// общую статистику вопросов questionIDs. Возвращает вопросы, по которым
+// были ответы.
+func (s *ItemAnalysisService) refreshScope(ctx context.Context, testID uint, questionIDs []uint, now time.Time) ([]uint, error) {
+	var responses []*domain.ItemResponse
+	var err error
+	if testID == 0 {
+		responses, err = s.repo.GetQuestionResponses(ctx, questionIDs)
+	} else {
+		responses, err = s.repo.GetTestResponses(ctx, testID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool)
+	answered := make([]uint, 0)
+	for _, response := range responses {
+		if !seen[response.QuestionID] {
+			seen[response.QuestionID] = true
+			answered = append(answered, response.QuestionID)
+		}
+	}
+
+	questions, err := s.repo.GetQuestions(ctx, answered)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*domain.Question, len(questions))
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+
+	result := domain.AnalyzeItems(testID, responses, byID, now)
+	if err := s.repo.Replace(ctx, testID, questionIDs, result, now); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("item statistics refreshed", zap.Uint("testID", testID), zap.Int("questions", len(result)))
+
+	return answered, nil
+}
+
+func (s *ItemAnalysisService) GetTestStats(ctx context.Context, testID uint) ([]*domain.QuestionStats, error) {
+	return s.repo.GetByTest(ctx, testID)
+}
+
+func (s *ItemAnalysisService) GetQuestionStats(ctx context.Context, questionID uint) ([]*domain.QuestionStats, error) {
+	return s.repo.GetByQuestion(ctx, questionID)
+}
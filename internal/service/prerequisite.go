@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"diprec_api/internal/domain"
+	"diprec_api/internal/repository/prerequisite"
+)
+
+// PrerequisiteService проверяет, открыты ли тесты для студента с учётом
+// предварительных условий самого теста и курса, в который он входит.
+type PrerequisiteService struct {
+	repo prerequisite.IPrerequisiteRepository
+}
+
+func NewPrerequisiteService(repo prerequisite.IPrerequisiteRepository) *PrerequisiteService {
+	return &PrerequisiteService{repo: repo}
+}
+
+func (s *PrerequisiteService) SetTestPrerequisites(ctx context.Context, testID uint, prerequisites []*domain.TestPrerequisite) error {
+	edges, err := s.repo.GetAllTestPrerequisites(ctx)
+	if err != nil {
+		return err
+	}
+
+	graph := make(map[uint][]uint)
+	for _, edge := range edges {
+		if edge.TestID != testID {
+			graph[edge.TestID] = append(graph[edge.TestID], edge.RequiredTestID)
+		}
+	}
+	for _, p := range prerequisites {
+		p.TestID = testID
+		graph[testID] = append(graph[testID], p.RequiredTestID)
+	}
+	if hasCycle(graph, testID) {
+		return domain.ErrPrerequisiteCycle
+	}
+
+	return s.repo.SetTestPrerequisites(ctx, testID, prerequisites)
+}
+
+func (s *PrerequisiteService) SetCoursePrerequisites(ctx context.Context, courseID uint, prerequisites []*domain.CoursePrerequisite) error {
+	edges, err := s.repo.GetAllCoursePrerequisites(ctx)
+	if err != nil {
+		return err
+	}
+
+	graph := make(map[uint][]uint)
+	for _, edge := range edges {
+		if edge.CourseID != courseID {
+			graph[edge.CourseID] = append(graph[edge.CourseID], edge.RequiredCourseID)
+		}
+	}
+	for _, p := range prerequisites {
+		p.CourseID = courseID
+		graph[courseID] = append(graph[courseID], p.RequiredCourseID)
+	}
+	if hasCycle(graph, courseID) {
+		return domain.ErrPrerequisiteCycle
+	}
+
+	return s.repo.SetCoursePrerequisites(ctx, courseID, prerequisites)
+}
+
+func (s *PrerequisiteService) GetTestPrerequisites(ctx context.Context, testID uint) ([]*domain.TestPrerequisite, error) {
+	return s.repo.GetTestPrerequisites(ctx, []uint{testID})
+}
+
+func (s *PrerequisiteService) GetCoursePrerequisites(ctx context.Context, courseID uint) ([]*domain.CoursePrerequisite, error) {
+	return s.repo.GetCoursePrerequisites(ctx, []uint{courseID})
+}
+
+// Resolve выставляет тестам флаг Locked и список невыполненных условий
+// для пользователя userID.
+func (s *PrerequisiteService) Resolve(ctx context.Context, userID uint, tests []*domain.Test) error {
+	if len(tests) == 0 {
+		return nil
+	}
+
+	testIDs := make([]uint, len(tests))
+	for i, test := range tests {
+		testIDs[i] = test.ID
+	}
+
+	testPrerequisites, err := s.repo.GetTestPrerequisites(ctx, testIDs)
+	if err != nil {
+		return err
+	}
+
+	testCourses, err := s.repo.GetTestCourses(ctx, testIDs)
+	if err != nil {
+		return err
+	}
+	courseIDs := make([]uint, 0, len(testCourses))
+	for _, link := range testCourses {
+		courseIDs = append(courseIDs, link.CourseID)
+	}
+
+	coursePrerequisites, err := s.repo.GetCoursePrerequisites(ctx, courseIDs)
+	if err != nil {
+		return err
+	}
+	requiredCourseIDs := make([]uint, 0, len(coursePrerequisites))
+	for _, p := range coursePrerequisites {
+		requiredCourseIDs = append(requiredCourseIDs, p.RequiredCourseID)
+	}
+
+	requiredCourseTests, err := s.repo.GetCourseTests(ctx, requiredCourseIDs)
+	if err != nil {
+		return err
+	}
+
+	requiredTestIDs := make([]uint, 0, len(testPrerequisites)+len(requiredCourseTests))
+	for _, p := range testPrerequisites {
+		requiredTestIDs = append(requiredTestIDs, p.RequiredTestID)
+	}
+	for _, link := range requiredCourseTests {
+		requiredTestIDs = append(requiredTestIDs, link.TestID)
+	}
+
+	userTests, err := s.repo.GetUserTests(ctx, userID, requiredTestIDs)
+	if err != nil {
+		return err
+	}
+	// пересдача не закрывает уже открытые тесты: учитывается итог по
+	// завершённым попыткам
+	progress := make(map[uint]uint, len(userTests))
+	for _, ut := range userTests {
+		if ut.HasCompletedAttempt() {
+			progress[ut.TestID] = ut.Progress
+		}
+	}
+
+	courseProgress := make(map[uint]uint)
+	courseTestCount := make(map[uint]uint)
+	for _, link := range requiredCourseTests {
+		courseProgress[link.CourseID] += progress[link.TestID]
+		courseTestCount[link.CourseID]++
+	}
+	for courseID, count := range courseTestCount {
+		courseProgress[courseID] /= count
+	}
+
+	for _, test := range tests {
+		test.Locked = false
+		test.Requirements = nil
+
+		for _, p := range testPrerequisites {
+			if p.TestID != test.ID {
+				continue
+			}
+			current, finished := progress[p.RequiredTestID]
+			if !finished || current < p.MinProgress {
+				test.Requirements = append(test.Requirements, p.ToPrerequisiteResponse(current))
+			}
+		}
+
+		for _, link := range testCourses {
+			if link.TestID != test.ID {
+				continue
+			}
+			for _, p := range coursePrerequisites {
+				if p.CourseID != link.CourseID {
+					continue
+				}
+				if _, hasTests := courseTestCount[p.RequiredCourseID]; !hasTests {
+					continue
+				}
+				if current := courseProgress[p.RequiredCourseID]; current < p.MinProgress {
+					test.Requirements = append(test.Requirements, p.ToPrerequisiteResponse(current))
+				}
+			}
+		}
+
+		test.Locked = len(test.Requirements) > 0
+	}
+
+	return nil
+}
+
+// Check возвращает ErrTestLocked с перечнем невыполненных условий,
+// если тест для пользователя ещё закрыт.
+func (s *PrerequisiteService) Check(ctx context.Context, userID uint, test *domain.Test) error {
+	if err := s.Resolve(ctx, userID, []*domain.Test{test}); err != nil {
+		return err
+	}
+	if !test.Locked {
+		return nil
+	}
+
+	unmet := make([]string, len(test.Requirements))
+	for i, requirement := range test.Requirements {
+		unmet[i] = requirement.String()
+	}
+	return fmt.Errorf("%w: %s", domain.ErrTestLocked, strings.Join(unmet, ", "))
+}
+
+func hasCycle(graph map[uint][]uint, start uint) bool {
+	visited := make(map[uint]bool)
+	stack := []uint{}
+	stack = append(stack, graph[start]...)
+
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if node == start {
+			return true
+		}
+		if visited[node] {
+			continue
+		}
+		visited[node] = true
+		stack = append(stack, graph[node]...)
+	}
+
+	return false
+}
@@ -9,3 +9,12 @@ type UpdateCourseDTO struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
+
+type PrerequisiteDTO struct {
+	CourseID    uint `json:"courseId" binding:"required"`
+	MinProgress uint `json:"minProgress" binding:"max=100"`
+}
+
+type SetPrerequisitesDTO struct {
+	Prerequisites []PrerequisiteDTO `json:"prerequisites" binding:"dive"`
+}
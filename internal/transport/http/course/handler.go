@@ -2,6 +2,7 @@ package course
 
 import (
 	"diprec_api/internal/domain"
+	"diprec_api/internal/service"
 	"diprec_api/internal/usecase/course"
 	"errors"
 	"net/http"
@@ -25,6 +26,7 @@ func NewCourseHandler(cu course.ICourseUsecase, logger *zap.Logger) *CourseHandl
 
 // Create godoc
 // @Summary Создать курс
+// @Description Создатель записывается на курс и становится его персоналом
 // @Tags Course
 // @Security BearerAuth
 // @Produce json
@@ -44,7 +46,7 @@ func (h *CourseHandler) Create(c *gin.Context) {
 	course, err := h.cu.Create(c.Request.Context(), &domain.Course{
 		Name:        req.Name,
 		Description: req.Description,
-	})
+	}, c.GetUint("userID"))
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -85,6 +87,7 @@ func (h *CourseHandler) Get(c *gin.Context) {
 // @Success 200 {object} domain.CourseResponseWithTests
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
 // @Failure 404 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /course/{id} [get]
@@ -99,7 +102,7 @@ func (h *CourseHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	course, err := h.cu.GetById(c.Request.Context(), uint(id), userID)
+	course, err := h.cu.GetById(c.Request.Context(), uint(id), userID, c.GetString("role"))
 	if err != nil {
 		if errors.Is(err, domain.ErrCourseNotFound) {
 			h.logger.Error("Get course failed", zap.Error(err))
@@ -111,6 +114,8 @@ func (h *CourseHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	course.Tests = service.VisibleTests(course.Tests, c.GetString("role"))
+
 	response := course.ToCourseResponseWithTests()
 	c.JSON(http.StatusOK, response)
 }
@@ -233,3 +238,89 @@ func (h *CourseHandler) Enroll(c *gin.Context) {
 
 	c.JSON(http.StatusOK, nil)
 }
+
+// GetPrerequisites godoc
+// @Summary Получить предварительные условия курса
+// @Tags Course
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID курса"
+// @Success 200 {array} domain.PrerequisiteResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /course/{id}/prerequisites [get]
+func (h *CourseHandler) GetPrerequisites(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	prerequisites, err := h.cu.GetPrerequisites(c.Request.Context(), uint(courseID))
+	if err != nil {
+		h.logger.Error("Get course prerequisites failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToCoursePrerequisitesResponse(prerequisites))
+}
+
+// SetPrerequisites godoc
+// @Summary Задать предварительные условия курса (учитель)
+// @Description Тесты курса откроются после того, как средний результат по каждому из указанных курсов достигнет minProgress
+// @Tags Course
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID курса"
+// @Param input body SetPrerequisitesDTO true "Список предварительных условий"
+// @Success 200 {array} domain.PrerequisiteResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /course/{id}/prerequisites [put]
+func (h *CourseHandler) SetPrerequisites(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req SetPrerequisitesDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	prerequisites := make([]*domain.CoursePrerequisite, len(req.Prerequisites))
+	for i, p := range req.Prerequisites {
+		prerequisites[i] = &domain.CoursePrerequisite{
+			RequiredCourseID: p.CourseID,
+			MinProgress:      p.MinProgress,
+		}
+	}
+
+	result, err := h.cu.SetPrerequisites(c.Request.Context(), uint(courseID), prerequisites)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrCourseNotFound):
+			h.logger.Error("Set course prerequisites failed", zap.Error(err))
+			c.JSON(http.StatusNotFound, domain.Error{Message: err.Error()})
+		case errors.Is(err, domain.ErrPrerequisiteCycle):
+			h.logger.Warn("Set course prerequisites failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, domain.Error{Message: err.Error()})
+		default:
+			h.logger.Error("Set course prerequisites failed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToCoursePrerequisitesResponse(result))
+}
@@ -0,0 +1,16 @@
+package group
+
+import "time"
+
+type CreateGroupDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AddUsersDTO struct {
+	UserIDs []uint `json:"userIds" binding:"required"`
+}
+
+type TestOverrideDTO struct {
+	Deadline  *time.Time `json:"deadline"`
+	Available *bool      `json:"available"`
+}
@@ -0,0 +1,334 @@
+package group
+
+import (
+	"diprec_api/internal/domain"
+	"diprec_api/internal/usecase/group"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type GroupHandler struct {
+	gu     group.IGroupUsecase
+	logger *zap.Logger
+}
+
+func NewGroupHandler(gu group.IGroupUsecase, logger *zap.Logger) *GroupHandler {
+	return &GroupHandler{
+		gu:     gu,
+		logger: logger.Named("GroupHandler"),
+	}
+}
+
+// Create godoc
+// @Summary Создать группу в курсе
+// @Tags Group
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID курса"
+// @Param input body CreateGroupDTO true "Название группы"
+// @Success 201 {object} domain.GroupResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /course/{id}/groups [post]
+func (h *GroupHandler) Create(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req CreateGroupDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	group, err := h.gu.Create(c.Request.Context(), &domain.Group{
+		CourseID: uint(courseID),
+		Name:     req.Name,
+	})
+	if err != nil {
+		h.logger.Error("Create group failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group.ToGroupResponse())
+}
+
+// GetByCourse godoc
+// @Summary Получить группы курса
+// @Tags Group
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID курса"
+// @Success 200 {array} domain.GroupResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /course/{id}/groups [get]
+func (h *GroupHandler) GetByCourse(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	groups, err := h.gu.GetByCourseID(c.Request.Context(), uint(courseID))
+	if err != nil {
+		h.logger.Error("Get groups failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToGroupsResponse(groups))
+}
+
+// GetByID godoc
+// @Summary Получить группу по ID
+// @Tags Group
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID группы"
+// @Success 200 {object} domain.GroupResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /group/{id} [get]
+func (h *GroupHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	group, err := h.gu.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Error("Get group failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group.ToGroupResponse())
+}
+
+// Delete godoc
+// @Summary Удалить группу
+// @Tags Group
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID группы"
+// @Success 200 "Группа удалена"
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /group/{id} [delete]
+func (h *GroupHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	if err := h.gu.Delete(c.Request.Context(), uint(id)); err != nil {
+		h.logger.Error("Delete group failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// AddUsers godoc
+// @Summary Добавить студентов в группу
+// @Tags Group
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы"
+// @Param input body AddUsersDTO true "ID студентов"
+// @Success 200 {object} domain.GroupResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /group/{id}/users [post]
+func (h *GroupHandler) AddUsers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req AddUsersDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	group, err := h.gu.AddUsers(c.Request.Context(), uint(id), req.UserIDs)
+	if err != nil {
+		h.logger.Error("Add users to group failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group.ToGroupResponse())
+}
+
+// RemoveUser godoc
+// @Summary Исключить студента из группы
+// @Tags Group
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID группы"
+// @Param userId path int true "ID студента"
+// @Success 200 "Студент исключён"
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /group/{id}/users/{userId} [delete]
+func (h *GroupHandler) RemoveUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	if err := h.gu.RemoveUser(c.Request.Context(), uint(id), uint(userID)); err != nil {
+		h.logger.Error("Remove user from group failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// SetTestOverride godoc
+// @Summary Переопределить дедлайн и доступность теста для группы
+// @Tags Group
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы"
+// @Param testId path int true "ID теста"
+// @Param input body TestOverrideDTO true "Дедлайн и доступность теста для группы"
+// @Success 200 {object} domain.GroupResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /group/{id}/tests/{testId} [put]
+func (h *GroupHandler) SetTestOverride(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	testID, err := strconv.Atoi(c.Param("testId"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req TestOverrideDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	group, err := h.gu.SetTestOverride(c.Request.Context(), &domain.GroupTest{
+		GroupID:   uint(id),
+		TestID:    uint(testID),
+		Deadline:  req.Deadline,
+		Available: req.Available,
+	})
+	if err != nil {
+		h.logger.Error("Set test override failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group.ToGroupResponse())
+}
+
+// DeleteTestOverride godoc
+// @Summary Сбросить переопределение теста для группы
+// @Tags Group
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID группы"
+// @Param testId path int true "ID теста"
+// @Success 200 "Переопределение сброшено"
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /group/{id}/tests/{testId} [delete]
+func (h *GroupHandler) DeleteTestOverride(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	testID, err := strconv.Atoi(c.Param("testId"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	if err := h.gu.DeleteTestOverride(c.Request.Context(), uint(id), uint(testID)); err != nil {
+		h.logger.Error("Delete test override failed", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrGroupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrCourseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrGroupUserNotEnrolled):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrGroupTestOutsideCourse):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"diprec_api/internal/domain"
+	"diprec_api/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CourseAccess пропускает к курсу из параметра :id только записанных
+// на него студентов и преподавателей.
+func CourseAccess(policy *service.AccessPolicy, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		courseID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+			return
+		}
+
+		err = policy.CanViewCourse(c.Request.Context(), uint(courseID), c.GetUint("userID"), c.GetString("role"))
+		if err != nil {
+			abortWithAccessError(c, logger, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TestAccess пропускает к тесту из параметра :id. Черновики скрыты от
+// студентов, а сам тест доступен только записанным на его курс.
+func TestAccess(policy *service.AccessPolicy, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		testID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+			return
+		}
+
+		err = policy.CanViewTest(c.Request.Context(), uint(testID), c.GetUint("userID"), c.GetString("role"))
+		if err != nil {
+			abortWithAccessError(c, logger, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TestAttemptAccess дополнительно к TestAccess требует, чтобы тест был запущен.
+func TestAttemptAccess(policy *service.AccessPolicy, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		testID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+			return
+		}
+
+		err = policy.CanAttemptTest(c.Request.Context(), uint(testID), c.GetUint("userID"), c.GetString("role"))
+		if err != nil {
+			abortWithAccessError(c, logger, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AttemptAccess пропускает к попытке из параметра :id её владельца и
+// персонал курса теста.
+func AttemptAccess(policy *service.AccessPolicy, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attemptID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+			return
+		}
+
+		err = policy.CanViewAttempt(c.Request.Context(), uint(attemptID), c.GetUint("userID"), c.GetString("role"))
+		if err != nil {
+			abortWithAccessError(c, logger, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func abortWithAccessError(c *gin.Context, logger *zap.Logger, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCourseNotFound), errors.Is(err, domain.ErrTestNotFound), errors.Is(err, domain.ErrAttemptNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, domain.Error{Message: err.Error()})
+	case errors.Is(err, domain.ErrNotEnrolled), errors.Is(err, domain.ErrTestEnded):
+		logger.Warn("Access denied", zap.Uint("userID", c.GetUint("userID")), zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusForbidden, domain.Error{Message: err.Error()})
+	default:
+		logger.Error("Access check failed", zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, domain.Error{Message: domain.ErrInternalServer.Error()})
+	}
+}
@@ -5,6 +5,11 @@ type CreateQuestionDTO struct {
 	Type     string                 `json:"type" enums:"SINGLE,TEXT,NUMBER,MULTIPLE" example:"SINGLE"`
 	Variants map[string]interface{} `json:"variants"`
 	Answer   interface{}            `json:"answer"`
+	// Tag и Difficulty - тема и сложность вопроса для пулов тестов.
+	Tag        string `json:"tag"`
+	Difficulty uint   `json:"difficulty" binding:"max=5"`
+	// PartialCredit - правило частичного зачёта для MULTIPLE.
+	PartialCredit string `json:"partialCredit" binding:"omitempty,oneof=ALL_OR_NOTHING PROPORTIONAL PENALTY" enums:"ALL_OR_NOTHING,PROPORTIONAL,PENALTY"`
 }
 
 type UpdateQuestionDTO struct {
@@ -12,6 +17,11 @@ type UpdateQuestionDTO struct {
 	Type     string                 `json:"type" enums:"SINGLE,TEXT,NUMBER,MULTIPLE" example:"SINGLE"`
 	Variants map[string]interface{} `json:"variants"`
 	Answer   interface{}            `json:"answer"`
+	// Tag и Difficulty - тема и сложность вопроса для пулов тестов.
+	Tag        string `json:"tag"`
+	Difficulty uint   `json:"difficulty" binding:"max=5"`
+	// PartialCredit - правило частичного зачёта для MULTIPLE.
+	PartialCredit string `json:"partialCredit" binding:"omitempty,oneof=ALL_OR_NOTHING PROPORTIONAL PENALTY" enums:"ALL_OR_NOTHING,PROPORTIONAL,PENALTY"`
 }
 
 type CheckAnswerDTO struct {
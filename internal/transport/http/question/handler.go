@@ -4,6 +4,7 @@ import (
 	"diprec_api/internal/domain"
 	"diprec_api/internal/pkg/utils"
 	"diprec_api/internal/usecase/question"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -44,10 +45,13 @@ func (h *QuestionHandler) Create(c *gin.Context) {
 	}
 
 	question, err := h.qu.Create(c.Request.Context(), &domain.Question{
-		Title:    req.Title,
-		Type:     domain.Type(req.Type),
-		Variants: utils.ParseMapToJSON(req.Variants),
-		Answer:   utils.ParseToJSON(req.Answer),
+		Title:         req.Title,
+		Type:          domain.Type(req.Type),
+		Variants:      utils.ParseMapToJSON(req.Variants),
+		Answer:        utils.ParseToJSON(req.Answer),
+		Tag:           req.Tag,
+		Difficulty:    req.Difficulty,
+		PartialCredit: domain.PartialCredit(req.PartialCredit),
 	})
 	if err != nil {
 		h.logger.Warn("Create error", zap.Error(err))
@@ -140,11 +144,14 @@ func (h *QuestionHandler) Update(c *gin.Context) {
 	}
 
 	question, err := h.qu.Update(c.Request.Context(), &domain.Question{
-		ID:       uint(id),
-		Title:    req.Title,
-		Type:     domain.Type(req.Type),
-		Variants: utils.ParseMapToJSON(req.Variants),
-		Answer:   utils.ParseToJSON(req.Answer),
+		ID:            uint(id),
+		Title:         req.Title,
+		Type:          domain.Type(req.Type),
+		Variants:      utils.ParseMapToJSON(req.Variants),
+		Answer:        utils.ParseToJSON(req.Answer),
+		Tag:           req.Tag,
+		Difficulty:    req.Difficulty,
+		PartialCredit: domain.PartialCredit(req.PartialCredit),
 	})
 	if err != nil {
 		h.logger.Warn("Update error", zap.Error(err))
@@ -188,6 +195,7 @@ func (h *QuestionHandler) Delete(c *gin.Context) {
 
 // Check godoc
 // @Summary Проверить вопрос
+// @Description Ответ на вопрос теста (testId) принимается только в открытой попытке студента до закрытия приёма попыток. В результат попытки засчитывается первый проверенный ответ на вопрос; если при проверке был показан правильный ответ, повторная проверка отклоняется (409). Правильный ответ (answer) возвращается, только если его разрешает политика показа ответов теста. Вне теста его видит только учитель
 // @Tags Question
 // @Security BearerAuth
 // @Produce json
@@ -196,6 +204,9 @@ func (h *QuestionHandler) Delete(c *gin.Context) {
 // @Success 200 {object} domain.QuestionAnswer
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /question/{id}/check [post]
 func (h *QuestionHandler) Check(c *gin.Context) {
@@ -216,12 +227,68 @@ func (h *QuestionHandler) Check(c *gin.Context) {
 
 	userID := c.GetUint("userID")
 
-	result, err := h.qu.Check(c.Request.Context(), uint(id), userID, req.Answer, req.TestId)
+	result, err := h.qu.Check(c.Request.Context(), uint(id), userID, c.GetString("role"), req.Answer, req.TestId)
 	if err != nil {
 		h.logger.Warn("Check error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
+
+// GetStats godoc
+// @Summary Статистика вопроса
+// @Description Первая запись - по всем тестам (testId не указан), далее - по каждому тесту. Пересчитывается периодически
+// @Tags Question
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID вопроса"
+// @Success 200 {array} domain.QuestionStatsResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /question/{id}/stats [get]
+func (h *QuestionHandler) GetStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	stats, err := h.qu.GetStats(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Warn("Stats error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToQuestionsStatsResponse(stats))
+}
+
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrQuestionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrNotEnrolled):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTestEnded):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrDeadlinePassed):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTestNotStarted):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrAttemptExpired):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrQuestionNotInAttempt):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrAnswerAlreadyRevealed):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,25 +1,90 @@
 package test
 
-import "time"
+import (
+	"diprec_api/internal/domain"
+	"time"
+)
 
 type CreateTestDTO struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Deadline    time.Time `json:"deadline"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Deadline      time.Time  `json:"deadline"`
+	MaxAttempts   uint       `json:"maxAttempts"`
+	TimeLimit     uint       `json:"timeLimit" example:"45"`
+	OpensAt       *time.Time `json:"opensAt"`
+	ScoringPolicy string     `json:"scoringPolicy" binding:"omitempty,oneof=BEST LAST AVERAGE" enums:"BEST,LAST,AVERAGE" example:"LAST"`
+	RevealPolicy  string     `json:"revealPolicy" binding:"omitempty,oneof=IMMEDIATELY AFTER_SUBMIT AFTER_DEADLINE NEVER" enums:"IMMEDIATELY,AFTER_SUBMIT,AFTER_DEADLINE,NEVER" example:"AFTER_SUBMIT"`
+	// ShuffleQuestions и ShuffleVariants - перемешивать ли для студентов
+	// порядок вопросов и вариантов ответа.
+	ShuffleQuestions *bool `json:"shuffleQuestions"`
+	ShuffleVariants  *bool `json:"shuffleVariants"`
+	// LateHours - сколько часов после дедлайна принимаются попытки со
+	// штрафом LatePenalty в LatePenaltyPercent процентов.
+	LateHours          uint   `json:"lateHours" example:"24"`
+	LatePenalty        string `json:"latePenalty" binding:"omitempty,oneof=NONE LINEAR STEPPED" enums:"NONE,LINEAR,STEPPED" example:"LINEAR"`
+	LatePenaltyPercent uint   `json:"latePenaltyPercent" binding:"max=100" example:"20"`
+	// Adaptive - выдавать вопросы адаптивно. Тест останавливается после
+	// AdaptiveMaxItems вопросов или когда стандартная ошибка оценки
+	// способности не больше AdaptiveTargetSE.
+	Adaptive         *bool   `json:"adaptive"`
+	AdaptiveMaxItems uint    `json:"adaptiveMaxItems" example:"15"`
+	AdaptiveTargetSE float64 `json:"adaptiveTargetSE" binding:"omitempty,gt=0,lt=1" example:"0.4"`
 }
 
 type UpdateTestDTO struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Deadline    time.Time `json:"deadline"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Deadline      time.Time  `json:"deadline"`
+	MaxAttempts   *uint      `json:"maxAttempts"`
+	TimeLimit     *uint      `json:"timeLimit" example:"45"`
+	OpensAt       *time.Time `json:"opensAt"`
+	ScoringPolicy string     `json:"scoringPolicy" binding:"omitempty,oneof=BEST LAST AVERAGE" enums:"BEST,LAST,AVERAGE" example:"LAST"`
+	RevealPolicy  string     `json:"revealPolicy" binding:"omitempty,oneof=IMMEDIATELY AFTER_SUBMIT AFTER_DEADLINE NEVER" enums:"IMMEDIATELY,AFTER_SUBMIT,AFTER_DEADLINE,NEVER" example:"AFTER_SUBMIT"`
+	// ShuffleQuestions и ShuffleVariants - перемешивать ли для студентов
+	// порядок вопросов и вариантов ответа.
+	ShuffleQuestions *bool `json:"shuffleQuestions"`
+	ShuffleVariants  *bool `json:"shuffleVariants"`
+	// LateHours - сколько часов после дедлайна принимаются попытки со
+	// штрафом LatePenalty в LatePenaltyPercent процентов.
+	LateHours          *uint  `json:"lateHours" example:"24"`
+	LatePenalty        string `json:"latePenalty" binding:"omitempty,oneof=NONE LINEAR STEPPED" enums:"NONE,LINEAR,STEPPED" example:"LINEAR"`
+	LatePenaltyPercent *uint  `json:"latePenaltyPercent" binding:"omitempty,max=100" example:"20"`
+	// Adaptive - выдавать вопросы адаптивно. Тест останавливается после
+	// AdaptiveMaxItems вопросов или когда стандартная ошибка оценки
+	// способности не больше AdaptiveTargetSE.
+	Adaptive         *bool   `json:"adaptive"`
+	AdaptiveMaxItems uint    `json:"adaptiveMaxItems" example:"15"`
+	AdaptiveTargetSE float64 `json:"adaptiveTargetSE" binding:"omitempty,gt=0,lt=1" example:"0.4"`
+	// ClearOpensAt отменяет запланированную публикацию теста.
+	ClearOpensAt bool `json:"clearOpensAt" binding:"excluded_with=OpensAt"`
 }
 
 type AttachQuestionDTO struct {
 	QuestionID int `json:"questionId"`
+	// Position - индекс вставки с нуля, без него вопрос добавляется в конец.
+	Position *int `json:"position" binding:"omitempty,min=0"`
+	// Points - баллы за вопрос в этом тесте, по умолчанию один.
+	Points float64 `json:"points" binding:"omitempty,gt=0"`
+}
+
+type QuestionLinkDTO struct {
+	QuestionID uint    `json:"questionId" binding:"required"`
+	Points     float64 `json:"points" binding:"omitempty,gt=0"`
+}
+
+type BatchQuestionsDTO struct {
+	Replace bool              `json:"replace"`
+	Attach  []QuestionLinkDTO `json:"attach" binding:"dive"`
+	Detach  []uint            `json:"detach"`
+}
+
+type ReorderQuestionsDTO struct {
+	QuestionIDs []uint `json:"questionIds" binding:"required"`
 }
 
 type FinishTestDTO struct {
-	Progress uint `json:"progress"`
+	// Progress - устаревшее поле, принимается только в режиме совместимости.
+	Progress *uint `json:"progress,omitempty" binding:"omitempty,max=100"`
 }
 
 type RecommendTestDTO struct {
@@ -28,4 +93,99 @@ type RecommendTestDTO struct {
 	Deadline    time.Time `json:"deadline"     binding:"required"`
 	UserID      uint      `json:"user_id"      binding:"required"`
 	QuestionIDs []uint    `json:"question_ids" binding:"required"`
+	// RecommendationID - ключ рекомендации: повторный вызов с тем же ключом
+	// для студента и курса вернёт уже созданный тест. По умолчанию - name.
+	RecommendationID string `json:"recommendation_id"`
+	// ExpiresAt - когда тест скрывается и удаляется, по умолчанию через
+	// tests.recommendation_ttl.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type PrerequisiteDTO struct {
+	TestID      uint `json:"testId" binding:"required"`
+	MinProgress uint `json:"minProgress" binding:"max=100"`
+}
+
+type SetPrerequisitesDTO struct {
+	Prerequisites []PrerequisiteDTO `json:"prerequisites" binding:"dive"`
+}
+
+type PoolDTO struct {
+	Tag         string `json:"tag"`
+	QuestionIDs []uint `json:"questionIds"`
+	Count       uint   `json:"count" binding:"required,min=1"`
+	Difficulty  *uint  `json:"difficulty" binding:"omitempty,min=1,max=5"`
+}
+
+type SetPoolsDTO struct {
+	Pools []PoolDTO `json:"pools" binding:"dive"`
+}
+
+type DuplicateTestDTO struct {
+	CourseID           uint   `json:"courseId" binding:"required"`
+	Name               string `json:"name" binding:"required"`
+	DeadlineShiftHours int    `json:"deadlineShiftHours"`
+	CopyQuestions      bool   `json:"copyQuestions"`
+}
+
+type ImportTestDTO struct {
+	Package domain.TestPackage `json:"package"`
+	// Name и Deadline заменяют значения из пакета.
+	Name     string     `json:"name"`
+	Deadline *time.Time `json:"deadline"`
+	// QuestionMapping - ключ вопроса пакета и ID существующего вопроса,
+	// который нужно взять вместо него.
+	QuestionMapping map[string]uint `json:"questionMapping"`
+	OnConflict      string          `json:"onConflict" binding:"omitempty,oneof=CREATE USE_EXISTING" enums:"CREATE,USE_EXISTING" example:"CREATE"`
+}
+
+type SetPracticeDTO struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+type PracticeAnswerDTO struct {
+	QuestionID uint        `json:"questionId" binding:"required"`
+	Answer     interface{} `json:"answer" binding:"required"`
+}
+
+type SaveDraftDTO struct {
+	Answer interface{} `json:"answer" binding:"required"`
+}
+
+type SetExtensionDTO struct {
+	Deadline time.Time `json:"deadline" binding:"required"`
+}
+
+// Reset - поля теста, которые нужно сбросить: отмена публикации и
+// ограничения, явно заданные нулём.
+func (d *UpdateTestDTO) Reset() []string {
+	var reset []string
+	if d.ClearOpensAt {
+		reset = append(reset, "OpensAt")
+	}
+	if isZeroUint(d.MaxAttempts) {
+		reset = append(reset, "MaxAttempts")
+	}
+	if isZeroUint(d.TimeLimit) {
+		reset = append(reset, "TimeLimit")
+	}
+	if isZeroUint(d.LateHours) {
+		reset = append(reset, "LateHours")
+	}
+	if isZeroUint(d.LatePenaltyPercent) {
+		reset = append(reset, "LatePenaltyPercent")
+	}
+	return reset
+}
+
+// isZeroUint - значение передано и равно нулю.
+func isZeroUint(value *uint) bool {
+	return value != nil && *value == 0
+}
+
+func uintValue(value *uint) uint {
+	if value == nil {
+		return 0
+	}
+	return *value
 }
@@ -3,8 +3,11 @@ package test
 import (
 	"diprec_api/internal/domain"
 	"diprec_api/internal/usecase/test"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -52,9 +55,22 @@ func (h *TestHandler) Create(c *gin.Context) {
 	}
 
 	test, err := h.tu.Create(c.Request.Context(), &domain.Test{
-		Name:        req.Name,
-		Description: req.Description,
-		Deadline:    req.Deadline,
+		Name:               req.Name,
+		Description:        req.Description,
+		Deadline:           req.Deadline,
+		MaxAttempts:        req.MaxAttempts,
+		TimeLimit:          req.TimeLimit,
+		OpensAt:            req.OpensAt,
+		ScoringPolicy:      domain.ScoringPolicy(req.ScoringPolicy),
+		RevealPolicy:       domain.RevealPolicy(req.RevealPolicy),
+		ShuffleQuestions:   req.ShuffleQuestions,
+		ShuffleVariants:    req.ShuffleVariants,
+		LateHours:          req.LateHours,
+		LatePenalty:        domain.LatePenalty(req.LatePenalty),
+		LatePenaltyPercent: req.LatePenaltyPercent,
+		Adaptive:           req.Adaptive,
+		AdaptiveMaxItems:   req.AdaptiveMaxItems,
+		AdaptiveTargetSE:   req.AdaptiveTargetSE,
 	}, uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
@@ -67,6 +83,7 @@ func (h *TestHandler) Create(c *gin.Context) {
 
 // GetByID Get godoc
 // @Summary Получить тест по ID
+// @Description Вопросы адаптивного теста студент получает по одному в попытке: до её начала список вопросов пуст
 // @Tags Test
 // @Security BearerAuth
 // @Produce json
@@ -74,6 +91,8 @@ func (h *TestHandler) Create(c *gin.Context) {
 // @Success 200 {object} domain.TestResponseWithQuestions
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /test/{id} [get]
 func (h *TestHandler) GetByID(c *gin.Context) {
@@ -87,20 +106,24 @@ func (h *TestHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	test, err := h.tu.GetByID(c.Request.Context(), uint(id), userID)
+	test, err := h.tu.GetByID(c.Request.Context(), uint(id), userID, c.GetString("role"))
 	if err != nil {
 		h.logger.Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
-	isTeacher := c.GetString("role")
-	response := test.ToTestResponseWithQuestions(isTeacher == domain.RoleTeacher.String())
+	isTeacher := c.GetString("role") == domain.RoleTeacher.String()
+	if !isTeacher {
+		test.ShuffleFor(userID)
+	}
+	response := test.ToTestResponseWithQuestions(isTeacher)
 	c.JSON(http.StatusOK, response)
 }
 
 // Update godoc
 // @Summary Обновить тест
+// @Description Меняются только переданные поля. clearOpensAt отменяет запланированную публикацию, нулевые maxAttempts, timeLimit, lateHours и latePenaltyPercent снимают ограничения попыток, времени, поздней сдачи и штраф
 // @Tags Test
 // @Security BearerAuth
 // @Produce json
@@ -128,11 +151,24 @@ func (h *TestHandler) Update(c *gin.Context) {
 	}
 
 	test, err := h.tu.Update(c.Request.Context(), &domain.Test{
-		ID:          uint(id),
-		Name:        req.Name,
-		Description: req.Description,
-		Deadline:    req.Deadline,
-	})
+		ID:                 uint(id),
+		Name:               req.Name,
+		Description:        req.Description,
+		Deadline:           req.Deadline,
+		MaxAttempts:        uintValue(req.MaxAttempts),
+		TimeLimit:          uintValue(req.TimeLimit),
+		OpensAt:            req.OpensAt,
+		ScoringPolicy:      domain.ScoringPolicy(req.ScoringPolicy),
+		RevealPolicy:       domain.RevealPolicy(req.RevealPolicy),
+		ShuffleQuestions:   req.ShuffleQuestions,
+		ShuffleVariants:    req.ShuffleVariants,
+		LateHours:          uintValue(req.LateHours),
+		LatePenalty:        domain.LatePenalty(req.LatePenalty),
+		LatePenaltyPercent: uintValue(req.LatePenaltyPercent),
+		Adaptive:           req.Adaptive,
+		AdaptiveMaxItems:   req.AdaptiveMaxItems,
+		AdaptiveTargetSE:   req.AdaptiveTargetSE,
+	}, req.Reset()...)
 	if err != nil {
 		h.logger.Warn("Internal error", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
@@ -174,14 +210,16 @@ func (h *TestHandler) Delete(c *gin.Context) {
 
 // AttachQuestion godoc
 // @Summary Прикрепить вопрос к тесту
+// @Description Вставляет вопрос на позицию position или в конец списка. Уже прикреплённый вопрос переносится на позицию position. points задаёт баллы за вопрос в тесте
 // @Tags Test
 // @Security BearerAuth
 // @Produce json
 // @Param id path int true "ID теста"
-// @Param input body AttachQuestionDTO true "ID вопроса"
+// @Param input body AttachQuestionDTO true "ID вопроса, позиция и баллы"
 // @Success 200 "Вопрос прикреплен"
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /test/{id}/question [post]
 func (h *TestHandler) AttachQuestion(c *gin.Context) {
@@ -200,18 +238,108 @@ func (h *TestHandler) AttachQuestion(c *gin.Context) {
 		return
 	}
 
-	err = h.tu.AttachQuestion(c.Request.Context(), uint(testID), uint(req.QuestionID))
+	err = h.tu.AttachQuestion(c.Request.Context(), uint(testID), uint(req.QuestionID), req.Position, req.Points)
 	if err != nil {
 		h.logger.Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
+// ReorderQuestions godoc
+// @Summary Изменить порядок вопросов теста (учитель)
+// @Description Принимает полный список ID вопросов теста в новом порядке
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body ReorderQuestionsDTO true "ID вопросов по порядку"
+// @Success 200 {array} domain.QuestionResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/questions/order [put]
+func (h *TestHandler) ReorderQuestions(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req ReorderQuestionsDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	questions, err := h.tu.ReorderQuestions(c.Request.Context(), uint(testID), req.QuestionIDs)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToQuestionsResponse(questions, true))
+}
+
+// BatchQuestions godoc
+// @Summary Изменить набор вопросов теста (учитель)
+// @Description В одной транзакции открепляет вопросы detach и добавляет в конец вопросы attach, уже прикреплённые остаются на месте. При replace вопросы теста заменяются списком attach в его порядке. Все прикрепляемые вопросы должны существовать
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body BatchQuestionsDTO true "Изменения"
+// @Success 200 {array} domain.QuestionResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/questions [patch]
+func (h *TestHandler) BatchQuestions(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req BatchQuestionsDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	batch := &domain.QuestionBatch{
+		Replace: req.Replace,
+		Attach:  make([]*domain.TestQuestion, len(req.Attach)),
+		Detach:  req.Detach,
+	}
+	for i, q := range req.Attach {
+		batch.Attach[i] = &domain.TestQuestion{QuestionID: q.QuestionID, Points: q.Points}
+	}
+
+	questions, err := h.tu.BatchQuestions(c.Request.Context(), uint(testID), batch)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToQuestionsResponse(questions, true))
+}
+
 // DetachQuestion godoc
 // @Summary Открепить вопрос от теста
+// @Description Маршрут /test/delete/{testId}/{questionId} оставлен для совместимости
 // @Tags Test
 // @Security BearerAuth
 // @Accept json
@@ -222,10 +350,15 @@ func (h *TestHandler) AttachQuestion(c *gin.Context) {
 // @Failure 400 {object} domain.Error "Неверный запрос"
 // @Failure 401 {object} domain.Error "Unauthorized"
 // @Failure 500 {object} domain.Error "Internal Server Error"
+// @Router /test/{testId}/questions/{questionId} [delete]
 // @Router /test/delete/{testId}/{questionId} [delete]
 func (h *TestHandler) DetachQuestion(c *gin.Context) {
-	// Парсим ID теста
-	testID, err := strconv.Atoi(c.Param("testId"))
+	// Парсим ID теста: в старом маршруте он называется testId
+	testParam := c.Param("id")
+	if testParam == "" {
+		testParam = c.Param("testId")
+	}
+	testID, err := strconv.Atoi(testParam)
 	if err != nil {
 		h.logger.Warn("Invalid test ID", zap.Error(err))
 		c.JSON(http.StatusBadRequest, domain.Error{Message: "Неверный ID теста"})
@@ -251,6 +384,7 @@ func (h *TestHandler) DetachQuestion(c *gin.Context) {
 
 // StartTest godoc
 // @Summary Запустить тест (учитель)
+// @Description Переводит черновик в PROGRESS. Тест без вопросов запустить нельзя
 // @Tags Test
 // @Security BearerAuth
 // @Produce json
@@ -258,6 +392,8 @@ func (h *TestHandler) DetachQuestion(c *gin.Context) {
 // @Success 200 {object} domain.TestResponse
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /test/{id}/start [put]
 func (h *TestHandler) StartTest(c *gin.Context) {
@@ -269,13 +405,10 @@ func (h *TestHandler) StartTest(c *gin.Context) {
 		return
 	}
 
-	test, err := h.tu.Update(c.Request.Context(), &domain.Test{
-		ID:     uint(testID),
-		Status: domain.Progress,
-	})
+	test, err := h.tu.OpenTest(c.Request.Context(), uint(testID), c.GetUint("userID"))
 	if err != nil {
-		h.logger.Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		h.logger.Warn("Status change error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
@@ -286,6 +419,7 @@ func (h *TestHandler) StartTest(c *gin.Context) {
 
 // StopTest godoc
 // @Summary Остановить тест (учитель)
+// @Description Незавершённые попытки студентов сдаются с уже данными ответами
 // @Tags Test
 // @Security BearerAuth
 // @Produce json
@@ -293,6 +427,8 @@ func (h *TestHandler) StartTest(c *gin.Context) {
 // @Success 200 {object} domain.TestResponse
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /test/{id}/stop [put]
 func (h *TestHandler) StopTest(c *gin.Context) {
@@ -304,13 +440,10 @@ func (h *TestHandler) StopTest(c *gin.Context) {
 		return
 	}
 
-	test, err := h.tu.Update(c.Request.Context(), &domain.Test{
-		ID:     uint(testID),
-		Status: domain.Ended,
-	})
+	test, err := h.tu.CloseTest(c.Request.Context(), uint(testID), c.GetUint("userID"))
 	if err != nil {
-		h.logger.Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		h.logger.Warn("Status change error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
@@ -320,13 +453,16 @@ func (h *TestHandler) StopTest(c *gin.Context) {
 
 // BeginTest godoc
 // @Summary Приступить к тесту (студент)
+// @Description Начинает новую попытку или возвращает уже открытую
 // @Tags Test
 // @Security BearerAuth
 // @Produce json
 // @Param id path int true "ID теста"
-// @Success 200
+// @Success 200 {object} domain.AttemptResponse
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /test/{id}/begin [post]
 func (h *TestHandler) BeginTest(c *gin.Context) {
@@ -339,17 +475,17 @@ func (h *TestHandler) BeginTest(c *gin.Context) {
 		return
 	}
 
-	err = h.tu.StartTest(c.Request.Context(), &domain.UserTests{
+	attempt, err := h.tu.StartTest(c.Request.Context(), &domain.UserTests{
 		UserID: userID,
 		TestID: uint(testID),
 	})
 	if err != nil {
 		h.logger.Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, nil)
+	c.JSON(http.StatusOK, attempt.ToAttemptResponse())
 }
 
 // POST /test/{course_id}/recommend   (внутренний вызов от Python)
@@ -368,37 +504,50 @@ func (h *TestHandler) CreateRecommend(c *gin.Context) {
 		return
 	}
 
-	newTest, err := h.tu.CreateRecommendTest(
+	newTest, created, err := h.tu.CreateRecommendTest(
 		c.Request.Context(),
 		&domain.Test{
 			Name:        req.Name,
 			Description: req.Description,
 			Deadline:    req.Deadline,
 		},
-		uint(courseID),
-		req.UserID,
+		&domain.RecommendedTest{
+			UserID:           req.UserID,
+			CourseID:         uint(courseID),
+			RecommendationID: req.RecommendationID,
+			ExpiresAt:        req.ExpiresAt,
+		},
 		req.QuestionIDs,
 	)
 	if err != nil {
 		h.logger.Error("create recommend test", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, newTest.ToTestResponse())
+	// повторная рекомендация возвращает уже созданный тест
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, newTest.ToTestResponse())
 }
 
 // FinishTest godoc
 // @Summary Завершить тест (студент)
+// @Description Результат считается сервером по ответам, отправленным через проверку вопросов
 // @Tags Test
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "ID теста"
-// @Param input body FinishTestDTO true "Результат по тесту в процентах"
-// @Success 200
+// @Param input body FinishTestDTO false "Устаревшее: результат в процентах, только в режиме совместимости"
+// @Success 200 {object} domain.UserTestResponse
 // @Failure 400 {object} domain.Error
 // @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
 // @Failure 500 {object} domain.Error
 // @Router /test/{id}/finish [put]
 func (h *TestHandler) FinishTest(c *gin.Context) {
@@ -410,24 +559,929 @@ func (h *TestHandler) FinishTest(c *gin.Context) {
 		return
 	}
 
+	// тело необязательно: результат считает сервер
 	var req FinishTestDTO
-	if err := c.ShouldBind(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	userTest, err := h.tu.EndTest(c.Request.Context(), uint(testID), userID, req.Progress)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, userTest.ToUserTestResponse())
+}
+
+// ResumeAttempt godoc
+// @Summary Продолжить попытку
+// @Description Возвращает незавершённую попытку студента с вопросами, сохранёнными черновиками ответов и оставшимся временем
+// @Tags Attempt
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID попытки"
+// @Success 200 {object} domain.AttemptResumeResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /attempt/{id} [get]
+func (h *TestHandler) ResumeAttempt(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	attemptID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid attempt ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	resume, err := h.tu.ResumeAttempt(c.Request.Context(), uint(attemptID), userID)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	resume.Test.ShuffleFor(userID)
+	c.JSON(http.StatusOK, resume.ToAttemptResumeResponse(time.Now()))
+}
+
+// NextQuestion godoc
+// @Summary Следующий вопрос адаптивной попытки
+// @Description Сервер выбирает вопрос по оценке способности студента, пересчитанной по проверенным ответам. Пока на выданный вопрос нет ответа через проверку вопроса, возвращается он же. Когда выдано adaptiveMaxItems вопросов, стандартная ошибка оценки не больше adaptiveTargetSE или вопросы закончились, попытка завершается: finished = true, в result итог по тесту
+// @Tags Attempt
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID попытки"
+// @Success 200 {object} domain.AdaptiveStepResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /attempt/{id}/next [get]
+func (h *TestHandler) NextQuestion(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	attemptID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid attempt ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	step, err := h.tu.NextQuestion(c.Request.Context(), uint(attemptID), userID)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, step.ToAdaptiveStepResponse())
+}
+
+// SaveDraft godoc
+// @Summary Сохранить черновик ответа
+// @Description Сохраняет ответ на вопрос попытки без проверки, повторный вызов перезаписывает его. Черновики проверяются при сдаче попытки
+// @Tags Attempt
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID попытки"
+// @Param questionId path int true "ID вопроса"
+// @Param input body SaveDraftDTO true "Ответ"
+// @Success 200 {object} domain.AttemptDraftResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /attempt/{id}/answers/{questionId} [put]
+func (h *TestHandler) SaveDraft(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	attemptID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid attempt ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+	questionID, err := strconv.Atoi(c.Param("questionId"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid question ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req SaveDraftDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	draft, err := h.tu.SaveDraft(c.Request.Context(), uint(attemptID), userID, uint(questionID), req.Answer)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, draft.ToAttemptDraftResponse())
+}
+
+// SubmitAttempt godoc
+// @Summary Сдать попытку
+// @Description Проверяет все черновики ответов и завершает попытку
+// @Tags Attempt
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID попытки"
+// @Success 200 {object} domain.UserTestResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /attempt/{id}/submit [post]
+func (h *TestHandler) SubmitAttempt(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	attemptID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid attempt ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	userTest, err := h.tu.SubmitAttempt(c.Request.Context(), uint(attemptID), userID)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, userTest.ToUserTestResponse())
+}
+
+// ReviewAttempt godoc
+// @Summary Разбор попытки
+// @Description Ответы завершённой попытки рядом с правильными. Студенту доступен, когда это разрешает политика показа ответов теста
+// @Tags Attempt
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID попытки"
+// @Success 200 {object} domain.AttemptReviewResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /attempt/{id}/review [get]
+func (h *TestHandler) ReviewAttempt(c *gin.Context) {
+	attemptID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid attempt ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	review, err := h.tu.ReviewAttempt(c.Request.Context(), uint(attemptID), c.GetUint("userID"), c.GetString("role"))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review.ToAttemptReviewResponse())
+}
+
+// GetExtensions godoc
+// @Summary Индивидуальные продления дедлайна (учитель)
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {array} domain.TestExtensionResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/extensions [get]
+func (h *TestHandler) GetExtensions(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	extensions, err := h.tu.GetExtensions(c.Request.Context(), uint(testID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToTestExtensionsResponse(extensions))
+}
+
+// SetExtension godoc
+// @Summary Продлить дедлайн студенту (учитель)
+// @Description Индивидуальный дедлайн заменяет дедлайны теста и групп; окно поздней сдачи и штраф к студенту не применяются. Срок открытой попытки студента пересчитывается
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param userId path int true "ID студента"
+// @Param input body SetExtensionDTO true "Новый дедлайн"
+// @Success 200 {object} domain.TestExtensionResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/extensions/{userId} [put]
+func (h *TestHandler) SetExtension(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid user ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req SetExtensionDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Warn("Validation error, invalid body", zap.Error(err))
 		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
 		return
 	}
 
-	err = h.tu.EndTest(c.Request.Context(), &domain.UserTests{
-		UserID:   userID,
-		TestID:   uint(testID),
-		Progress: req.Progress,
-		Status:   domain.Ended,
+	extension, err := h.tu.SetExtension(c.Request.Context(), &domain.TestExtension{
+		TestID:    uint(testID),
+		UserID:    uint(userID),
+		Deadline:  req.Deadline,
+		GrantedBy: c.GetUint("userID"),
 	})
 	if err != nil {
 		h.logger.Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, domain.Error{Message: err.Error()})
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, nil)
+	c.JSON(http.StatusOK, extension.ToTestExtensionResponse())
+}
+
+// DeleteExtension godoc
+// @Summary Отменить продление дедлайна (учитель)
+// @Tags Test
+// @Security BearerAuth
+// @Param id path int true "ID теста"
+// @Param userId path int true "ID студента"
+// @Success 204 "Продление отменено"
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/extensions/{userId} [delete]
+func (h *TestHandler) DeleteExtension(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid user ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	if err := h.tu.DeleteExtension(c.Request.Context(), uint(testID), uint(userID)); err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPrerequisites godoc
+// @Summary Получить предварительные условия теста
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {array} domain.PrerequisiteResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/prerequisites [get]
+func (h *TestHandler) GetPrerequisites(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	prerequisites, err := h.tu.GetPrerequisites(c.Request.Context(), uint(testID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToTestPrerequisitesResponse(prerequisites))
+}
+
+// SetPrerequisites godoc
+// @Summary Задать предварительные условия теста (учитель)
+// @Description Полностью заменяет список тестов, которые нужно пройти с результатом не ниже minProgress
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body SetPrerequisitesDTO true "Список предварительных условий"
+// @Success 200 {array} domain.PrerequisiteResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/prerequisites [put]
+func (h *TestHandler) SetPrerequisites(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req SetPrerequisitesDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	prerequisites := make([]*domain.TestPrerequisite, len(req.Prerequisites))
+	for i, p := range req.Prerequisites {
+		prerequisites[i] = &domain.TestPrerequisite{
+			RequiredTestID: p.TestID,
+			MinProgress:    p.MinProgress,
+		}
+	}
+
+	result, err := h.tu.SetPrerequisites(c.Request.Context(), uint(testID), prerequisites)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToTestPrerequisitesResponse(result))
+}
+
+// GetAnswers godoc
+// @Summary История ответов по тесту
+// @Description Студент получает свои ответы. Преподаватель - ответы всех студентов или одного, если указан userId
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param userId query int false "ID студента (только для преподавателя)"
+// @Success 200 {array} domain.UserAnswerResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/answers [get]
+func (h *TestHandler) GetAnswers(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	userID := c.GetUint("userID")
+	if c.GetString("role") == domain.RoleTeacher.String() {
+		userID = 0
+		if userIDStr := c.Query("userId"); userIDStr != "" {
+			id, err := strconv.Atoi(userIDStr)
+			if err != nil {
+				h.logger.Warn("Validation error, invalid user ID", zap.Error(err))
+				c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+				return
+			}
+			userID = uint(id)
+		}
+	}
+
+	answers, err := h.tu.GetAnswers(c.Request.Context(), uint(testID), userID)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToUserAnswersResponse(answers))
+}
+
+// GetResults godoc
+// @Summary Результаты теста по группе (учитель)
+// @Description Статус, результат, время и число попыток каждого записанного студента, включая не приступавших, и сводная статистика
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {object} domain.TestResultsResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/results [get]
+func (h *TestHandler) GetResults(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	results, err := h.tu.GetResults(c.Request.Context(), uint(testID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results.ToTestResultsResponse())
+}
+
+// GetStats godoc
+// @Summary Статистика вопросов теста (учитель)
+// @Description Трудность (p-value), индекс дискриминации, выбор вариантов и среднее время по каждому вопросу. Пересчитывается периодически
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {array} domain.QuestionStatsResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/stats [get]
+func (h *TestHandler) GetStats(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	stats, err := h.tu.GetStats(c.Request.Context(), uint(testID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToQuestionsStatsResponse(stats))
+}
+
+// SetPools godoc
+// @Summary Задать пулы вопросов теста (учитель)
+// @Description Полностью заменяет правила случайной выборки вопросов. Вопросы выбираются при начале каждой попытки и фиксируются за ней
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body SetPoolsDTO true "Список пулов"
+// @Success 200 {array} domain.QuestionPoolResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/pools [put]
+func (h *TestHandler) SetPools(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req SetPoolsDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	pools := make([]*domain.QuestionPool, len(req.Pools))
+	for i, p := range req.Pools {
+		pools[i] = &domain.QuestionPool{
+			Tag:         p.Tag,
+			QuestionIDs: p.QuestionIDs,
+			Count:       p.Count,
+			Difficulty:  p.Difficulty,
+		}
+	}
+
+	result, err := h.tu.SetPools(c.Request.Context(), uint(testID), pools)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToQuestionPoolsResponse(result))
+}
+
+// Duplicate godoc
+// @Summary Копировать тест (учитель)
+// @Description Создаёт черновик с настройками, вопросами и пулами теста в указанном курсе. Дедлайн и время публикации сдвигаются на deadlineShiftHours. При copyQuestions вопросы копируются, иначе копия ссылается на те же вопросы
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body DuplicateTestDTO true "Параметры копии"
+// @Success 201 {object} domain.TestResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/duplicate [post]
+func (h *TestHandler) Duplicate(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req DuplicateTestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	shift := time.Duration(req.DeadlineShiftHours) * time.Hour
+	test, err := h.tu.Duplicate(c.Request.Context(), uint(testID), req.CourseID, req.Name, shift, req.CopyQuestions)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, test.ToTestResponse())
+}
+
+// Export godoc
+// @Summary Экспортировать тест (учитель)
+// @Description Возвращает версионированный пакет теста: настройки, вопросы с вариантами и ответами в порядке теста, их веса и пулы. Пакет импортируется через POST /course/{id}/tests/import
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {object} domain.TestPackage
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/export [get]
+func (h *TestHandler) Export(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	pkg, err := h.tu.Export(c.Request.Context(), uint(testID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pkg)
+}
+
+// Import godoc
+// @Summary Импортировать тест в курс (учитель)
+// @Description Создаёт черновик теста из пакета экспорта. Вопрос пакета берётся из questionMapping, иначе используется существующий вопрос с тем же заголовком и содержимым, иначе создаётся новый. Если есть вопрос с тем же заголовком, но другим содержимым, это конфликт: при onConflict=CREATE (по умолчанию) создаётся новый вопрос, при USE_EXISTING берётся существующий. Итог по каждому вопросу возвращается в questions
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID курса"
+// @Param input body ImportTestDTO true "Пакет и параметры импорта"
+// @Success 201 {object} domain.TestImportResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /course/{id}/tests/import [post]
+func (h *TestHandler) Import(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid course ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req ImportTestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	options := domain.TestImportOptions{
+		CourseID:   uint(courseID),
+		Name:       req.Name,
+		Deadline:   req.Deadline,
+		Mapping:    req.QuestionMapping,
+		OnConflict: domain.QuestionConflictPolicy(req.OnConflict),
+	}
+
+	imported, err := h.tu.Import(c.Request.Context(), &req.Package, options)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, imported.ToTestImportResponse())
+}
+
+// SetPractice godoc
+// @Summary Включить или выключить практику по тесту (учитель)
+// @Description Практика позволяет студентам отвечать на вопросы завершённого теста без влияния на результат. Включить её можно только после завершения теста и если политика показа ответов не NEVER
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body SetPracticeDTO true "Включить или выключить"
+// @Success 200 {object} domain.TestResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 409 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/practice [put]
+func (h *TestHandler) SetPractice(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req SetPracticeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	test, err := h.tu.SetPractice(c.Request.Context(), uint(testID), *req.Enabled)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, test.ToTestResponse())
+}
+
+// GetPractice godoc
+// @Summary Вопросы для практики по тесту
+// @Description Вопросы завершённого теста, включая вопросы из наборов пулов, без правильных ответов
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {object} domain.TestResponseWithQuestions
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/practice [get]
+func (h *TestHandler) GetPractice(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	test, err := h.tu.GetPractice(c.Request.Context(), uint(testID), userID)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, test.ToTestResponseWithQuestions(false))
+}
+
+// CheckPractice godoc
+// @Summary Проверить ответ в режиме практики
+// @Description Сразу возвращает оценку и правильный ответ. Отвечать можно сколько угодно раз: попытки, результат теста и история ответов не меняются
+// @Tags Test
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID теста"
+// @Param input body PracticeAnswerDTO true "Ответ"
+// @Success 200 {object} domain.QuestionAnswer
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 403 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/practice/check [post]
+func (h *TestHandler) CheckPractice(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	var req PracticeAnswerDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Validation error, invalid body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	result, err := h.tu.CheckPractice(c.Request.Context(), uint(testID), userID, req.QuestionID, req.Answer)
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetStatusHistory godoc
+// @Summary История статусов теста (учитель)
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID теста"
+// @Success 200 {array} domain.TestStatusChangeResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /test/{id}/status-history [get]
+func (h *TestHandler) GetStatusHistory(c *gin.Context) {
+	testID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid test ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	history, err := h.tu.GetStatusHistory(c.Request.Context(), uint(testID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToTestStatusChangesResponse(history))
+}
+
+// GetSchedule godoc
+// @Summary Расписание тестов курса (учитель)
+// @Description Предстоящие автоматические публикации по opensAt и закрытия по дедлайну
+// @Tags Test
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID курса"
+// @Success 200 {array} domain.ScheduledTransitionResponse
+// @Failure 400 {object} domain.Error
+// @Failure 401 {object} domain.Error
+// @Failure 404 {object} domain.Error
+// @Failure 500 {object} domain.Error
+// @Router /course/{id}/schedule [get]
+func (h *TestHandler) GetSchedule(c *gin.Context) {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("Validation error, invalid course ID", zap.Error(err))
+		c.JSON(http.StatusBadRequest, domain.Error{Message: domain.ErrInvalidRequestBody.Error()})
+		return
+	}
+
+	transitions, err := h.tu.GetSchedule(c.Request.Context(), uint(courseID))
+	if err != nil {
+		h.logger.Warn("Internal error", zap.Error(err))
+		c.JSON(errorStatusCode(err), domain.Error{Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ToScheduledTransitionsResponse(transitions))
+}
+
+func errorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrTestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrCourseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrQuestionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAttemptNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrExtensionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAnswersHidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTestNotAdaptive):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrPracticeUnavailable):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrPracticeAnswersHidden):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrTestNotEnded):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrStudentNotEnrolled):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrQuestionNotInAttempt):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrAttemptExpired):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrTestUnavailable):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTestLocked):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNoAttemptsLeft):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrDeadlinePassed):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrPrerequisiteCycle):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrClientProgressRejected):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrTestNotStarted):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidStatusTransition):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrTestHasNoQuestions):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidPool):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrPoolTooSmall):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrTestNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidQuestionOrder):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnsupportedPackage):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidTestPackage):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidQuestionBatch):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -4,36 +4,47 @@ import (
 	"context"
 	"diprec_api/internal/domain"
 	"diprec_api/internal/repository/course"
+	"diprec_api/internal/service"
 
 	"go.uber.org/zap"
 )
 
 type courseUsecase struct {
-	repo   course.ICourseRepository
-	logger *zap.Logger
+	repo          course.ICourseRepository
+	prerequisites *service.PrerequisiteService
+	logger        *zap.Logger
 }
 
 type ICourseUsecase interface {
-	Create(ctx context.Context, course *domain.Course) (*domain.Course, error)
+	Create(ctx context.Context, course *domain.Course, userID uint) (*domain.Course, error)
 	Update(ctx context.Context, course *domain.Course) (*domain.Course, error)
 	Delete(ctx context.Context, id uint) error
-	GetById(ctx context.Context, id, userID uint) (*domain.Course, error)
+	GetById(ctx context.Context, id, userID uint, role string) (*domain.Course, error)
 	Get(ctx context.Context) ([]*domain.Course, error)
 	Enroll(ctx context.Context, courseID uint, userID uint) error
+	GetPrerequisites(ctx context.Context, courseID uint) ([]*domain.CoursePrerequisite, error)
+	SetPrerequisites(ctx context.Context, courseID uint, prerequisites []*domain.CoursePrerequisite) ([]*domain.CoursePrerequisite, error)
 }
 
-func NewCourseUseCase(repo course.ICourseRepository, logger *zap.Logger) ICourseUsecase {
+func NewCourseUseCase(repo course.ICourseRepository, prerequisites *service.PrerequisiteService, logger *zap.Logger) ICourseUsecase {
 	return &courseUsecase{
-		repo:   repo,
-		logger: logger.Named("CourseUsecase"),
+		repo:          repo,
+		prerequisites: prerequisites,
+		logger:        logger.Named("CourseUsecase"),
 	}
 }
 
-func (u *courseUsecase) Create(ctx context.Context, course *domain.Course) (*domain.Course, error) {
+// Create создаёт курс и записывает на него создателя: так он становится
+// персоналом курса.
+func (u *courseUsecase) Create(ctx context.Context, course *domain.Course, userID uint) (*domain.Course, error) {
 	if err := u.repo.Create(ctx, course); err != nil {
 		return nil, err
 	}
 
+	if err := u.repo.EnrollUser(ctx, course.ID, userID); err != nil {
+		return nil, err
+	}
+
 	return course, nil
 }
 
@@ -53,15 +64,45 @@ func (u *courseUsecase) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
-func (u *courseUsecase) GetById(ctx context.Context, id, userID uint) (*domain.Course, error) {
-	course, err := u.repo.GetByID(ctx, id, userID)
+func (u *courseUsecase) GetById(ctx context.Context, id, userID uint, role string) (*domain.Course, error) {
+	course, err := u.repo.GetByID(ctx, id, userID, service.IsStaff(role))
 	if err != nil {
 		return nil, err
 	}
 
+	if err := u.applyUserDeadlines(ctx, course, userID); err != nil {
+		return nil, err
+	}
+
+	if err := u.prerequisites.Resolve(ctx, userID, course.Tests); err != nil {
+		return nil, err
+	}
+
 	return course, nil
 }
 
+// applyUserDeadlines подставляет в тесты курса дедлайн и доступность с
+// учётом групп студента и его индивидуальных продлений.
+func (u *courseUsecase) applyUserDeadlines(ctx context.Context, course *domain.Course, userID uint) error {
+	overrides, err := u.repo.GetGroupOverrides(ctx, course.ID, userID)
+	if err != nil {
+		return err
+	}
+	extensions, err := u.repo.GetExtensions(ctx, course.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, test := range course.Tests {
+		test.ApplyGroupOverrides(overrides)
+		for _, extension := range extensions {
+			test.ApplyExtension(extension)
+		}
+	}
+
+	return nil
+}
+
 func (u *courseUsecase) Get(ctx context.Context) ([]*domain.Course, error) {
 	courses, err := u.repo.Get(ctx)
 	if err != nil {
@@ -79,3 +120,24 @@ func (u *courseUsecase) Enroll(ctx context.Context, courseID uint, userID uint)
 
 	return nil
 }
+
+func (u *courseUsecase) GetPrerequisites(ctx context.Context, courseID uint) ([]*domain.CoursePrerequisite, error) {
+	prerequisites, err := u.prerequisites.GetCoursePrerequisites(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+func (u *courseUsecase) SetPrerequisites(ctx context.Context, courseID uint, prerequisites []*domain.CoursePrerequisite) ([]*domain.CoursePrerequisite, error) {
+	if _, err := u.repo.GetByID(ctx, courseID, 0, true); err != nil {
+		return nil, err
+	}
+
+	if err := u.prerequisites.SetCoursePrerequisites(ctx, courseID, prerequisites); err != nil {
+		return nil, err
+	}
+
+	return u.prerequisites.GetCoursePrerequisites(ctx, courseID)
+}
@@ -0,0 +1,127 @@
+package group
+
+import (
+	"context"
+	"diprec_api/internal/domain"
+	"diprec_api/internal/repository/group"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+type groupUsecase struct {
+	repo   group.IGroupRepository
+	logger *zap.Logger
+}
+
+type IGroupUsecase interface {
+	Create(ctx context.Context, group *domain.Group) (*domain.Group, error)
+	GetByCourseID(ctx context.Context, courseID uint) ([]*domain.Group, error)
+	GetByID(ctx context.Context, id uint) (*domain.Group, error)
+	Delete(ctx context.Context, id uint) error
+	AddUsers(ctx context.Context, groupID uint, userIDs []uint) (*domain.Group, error)
+	RemoveUser(ctx context.Context, groupID uint, userID uint) error
+	SetTestOverride(ctx context.Context, override *domain.GroupTest) (*domain.Group, error)
+	DeleteTestOverride(ctx context.Context, groupID uint, testID uint) error
+}
+
+func NewGroupUsecase(repo group.IGroupRepository, logger *zap.Logger) IGroupUsecase {
+	return &groupUsecase{
+		repo:   repo,
+		logger: logger.Named("GroupUsecase"),
+	}
+}
+
+func (u *groupUsecase) Create(ctx context.Context, group *domain.Group) (*domain.Group, error) {
+	if err := u.repo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (u *groupUsecase) GetByCourseID(ctx context.Context, courseID uint) ([]*domain.Group, error) {
+	groups, err := u.repo.GetByCourseID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (u *groupUsecase) GetByID(ctx context.Context, id uint) (*domain.Group, error) {
+	group, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (u *groupUsecase) Delete(ctx context.Context, id uint) error {
+	if err := u.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddUsers добавляет в группу студентов, записанных на её курс.
+func (u *groupUsecase) AddUsers(ctx context.Context, groupID uint, userIDs []uint) (*domain.Group, error) {
+	group, err := u.repo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := u.repo.GetNotEnrolled(ctx, group.CourseID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %v", domain.ErrGroupUserNotEnrolled, missing)
+	}
+
+	if err := u.repo.AddUsers(ctx, groupID, userIDs); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(ctx, groupID)
+}
+
+func (u *groupUsecase) RemoveUser(ctx context.Context, groupID uint, userID uint) error {
+	if err := u.repo.RemoveUser(ctx, groupID, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetTestOverride переопределяет для группы тест её курса.
+func (u *groupUsecase) SetTestOverride(ctx context.Context, override *domain.GroupTest) (*domain.Group, error) {
+	group, err := u.repo.GetByID(ctx, override.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	inCourse, err := u.repo.IsCourseTest(ctx, group.CourseID, override.TestID)
+	if err != nil {
+		return nil, err
+	}
+	if !inCourse {
+		return nil, domain.ErrGroupTestOutsideCourse
+	}
+
+	if err := u.repo.SetTestOverride(ctx, override); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(ctx, override.GroupID)
+}
+
+func (u *groupUsecase) DeleteTestOverride(ctx context.Context, groupID uint, testID uint) error {
+	if err := u.repo.DeleteTestOverride(ctx, groupID, testID); err != nil {
+		return err
+	}
+
+	return nil
+}
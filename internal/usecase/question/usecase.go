@@ -5,8 +5,11 @@ import (
 	"diprec_api/internal/domain"
 	"diprec_api/internal/infrastructure/kafka"
 	"diprec_api/internal/pkg/utils"
+	"diprec_api/internal/repository/answer"
 	"diprec_api/internal/repository/question"
 	"diprec_api/internal/repository/test"
+	"diprec_api/internal/service"
+	"errors"
 
 	"strconv"
 	"time"
@@ -15,10 +18,15 @@ import (
 )
 
 type questionUsecase struct {
-	repo     question.IQuestionRepository
-	testRepo test.ITestRepository
-	producer kafka.IKafkaProducer
-	logger   *zap.Logger
+	repo         question.IQuestionRepository
+	testRepo     test.ITestRepository
+	answerRepo   answer.IAnswerRepository
+	access       *service.AccessPolicy
+	itemAnalysis *service.ItemAnalysisService
+	producer     kafka.IKafkaProducer
+	logger       *zap.Logger
+	// timeLimitGrace - допуск к лимиту времени попытки на задержки сети.
+	timeLimitGrace time.Duration
 }
 
 type IQuestionUsecase interface {
@@ -27,11 +35,21 @@ type IQuestionUsecase interface {
 	GetByID(ctx context.Context, id uint) (*domain.Question, error)
 	Update(ctx context.Context, question *domain.Question) (*domain.Question, error)
 	Delete(ctx context.Context, id uint) error
-	Check(ctx context.Context, id, userID uint, answer interface{}, testId int) (*domain.QuestionAnswer, error)
+	Check(ctx context.Context, id, userID uint, role string, answer interface{}, testId int) (*domain.QuestionAnswer, error)
+	GetStats(ctx context.Context, id uint) ([]*domain.QuestionStats, error)
 }
 
-func NewQuestionUsecase(repo question.IQuestionRepository, testRepo test.ITestRepository, producer kafka.IKafkaProducer, logger *zap.Logger) IQuestionUsecase {
-	return &questionUsecase{repo, testRepo, producer, logger}
+func NewQuestionUsecase(
+	repo question.IQuestionRepository,
+	testRepo test.ITestRepository,
+	answerRepo answer.IAnswerRepository,
+	access *service.AccessPolicy,
+	itemAnalysis *service.ItemAnalysisService,
+	producer kafka.IKafkaProducer,
+	logger *zap.Logger,
+	timeLimitGrace time.Duration,
+) IQuestionUsecase {
+	return &questionUsecase{repo, testRepo, answerRepo, access, itemAnalysis, producer, logger, timeLimitGrace}
 }
 
 func (u *questionUsecase) Create(ctx context.Context, question *domain.Question) (*domain.Question, error) {
@@ -100,13 +118,44 @@ func (u *questionUsecase) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
-func (u *questionUsecase) Check(ctx context.Context, id, userID uint, answer interface{}, testId int) (*domain.QuestionAnswer, error) {
+// Check проверяет ответ и сохраняет его. Ответ на вопрос теста принимается,
+// только если студент может проходить этот тест. Правильный ответ
+// возвращается, только если его разрешает показать политика теста; вне
+// теста его видит только учитель.
+func (u *questionUsecase) Check(ctx context.Context, id, userID uint, role string, answer interface{}, testId int) (*domain.QuestionAnswer, error) {
 	question, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	isCorrect := question.CheckAnswer(answer)
+	var test *domain.Test
+	if testId > 0 {
+		if err := u.access.CanAttemptTest(ctx, uint(testId), userID, role); err != nil {
+			return nil, err
+		}
+
+		test, err = u.testRepo.GetByID(ctx, uint(testId), userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := service.ApplyUserDeadline(ctx, u.testRepo, test, userID); err != nil {
+			return nil, err
+		}
+
+		points, err := u.testRepo.GetQuestionPoints(ctx, test.ID)
+		if err != nil {
+			return nil, err
+		}
+		question.Points = points[question.ID]
+	}
+
+	score := question.Grade(answer)
+	isCorrect := score.Correct()
+
+	inAttempt, err := u.recordAnswer(ctx, test, userID, role, id, answer, score)
+	if err != nil {
+		return nil, err
+	}
 
 	courseID, err := u.testRepo.GetCourseIDByTestID(ctx, uint(testId))
 	if err != nil {
@@ -126,6 +175,8 @@ func (u *questionUsecase) Check(ctx context.Context, id, userID uint, answer int
 				Variants:   question.Variants,
 				Answer:     question.Answer,
 				IsCorrect:  isCorrect,
+				Score:      score.Score,
+				MaxScore:   score.Max,
 				UserID:     userID,
 				Timestamp:  time.Now(),
 			}
@@ -139,9 +190,144 @@ func (u *questionUsecase) Check(ctx context.Context, id, userID uint, answer int
 		}
 	}
 
-	return &domain.QuestionAnswer{
+	result := &domain.QuestionAnswer{
 		IsCorrect: isCorrect,
+		Score:     score.Score,
+		MaxScore:  score.Max,
 		Message:   utils.GenerateFeedbackMessage(isCorrect),
-		Answer:    question.Answer,
-	}, nil
+	}
+
+	if u.answerRevealed(test, role, inAttempt) {
+		result.Answer = question.Answer
+	}
+
+	return result, nil
+}
+
+// answerRevealed - можно ли показать пользователю правильный ответ на
+// вопрос теста test. inAttempt - ответ записан в открытую попытку.
+func (u *questionUsecase) answerRevealed(test *domain.Test, role string, inAttempt bool) bool {
+	if service.IsStaff(role) {
+		return true
+	}
+	if test == nil {
+		return false
+	}
+
+	return test.AnswerVisibleOnCheck(inAttempt)
+}
+
+// checkAttemptQuestion принимает ответы только на вопросы попытки: на
+// зафиксированные за ней при выборке из пулов или адаптивной выдаче, иначе -
+// на прикреплённые к тесту. В адаптивной попытке отвечать можно только на
+// выданные вопросы.
+func (u *questionUsecase) checkAttemptQuestion(ctx context.Context, test *domain.Test, attemptID, questionID uint) error {
+	ids, err := u.testRepo.GetAttemptQuestionIDs(ctx, attemptID)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 && !test.IsAdaptive() {
+		if test.HasQuestion(questionID) {
+			return nil
+		}
+		return domain.ErrQuestionNotInAttempt
+	}
+
+	for _, id := range ids {
+		if id == questionID {
+			return nil
+		}
+	}
+	return domain.ErrQuestionNotInAttempt
+}
+
+// checkNotRevealed запрещает повторную проверку вопроса попытки, если при
+// первой проверке был показан правильный ответ: иначе его можно было бы
+// просто переписать.
+func (u *questionUsecase) checkNotRevealed(ctx context.Context, test *domain.Test, userID uint, role string, questionID uint) error {
+	if !u.answerRevealed(test, role, true) {
+		return nil
+	}
+
+	answers, err := u.testRepo.GetAnswers(ctx, test.ID, userID)
+	if err != nil {
+		return err
+	}
+	for _, answer := range answers {
+		if answer.QuestionID == questionID {
+			return domain.ErrAnswerAlreadyRevealed
+		}
+	}
+	return nil
+}
+
+// recordAnswer сохраняет ответ в историю, а если это ответ на вопрос теста -
+// ещё и в лист ответов открытой попытки, по которому сервер посчитает
+// результат. Без открытой попытки, после закрытия приёма попыток с учётом
+// групп и продления студента и после окончания времени попытки ответы на
+// вопросы теста не принимаются. Учитель может проверять вопросы теста без
+// попытки - тогда ответ попадает только в историю. Если ключ показывается
+// при проверке, вопрос попытки можно проверить только один раз. Возвращает,
+// записан ли ответ в попытку.
+func (u *questionUsecase) recordAnswer(ctx context.Context, test *domain.Test, userID uint, role string, questionID uint, answer interface{}, score domain.AnswerScore) (bool, error) {
+	record := &domain.UserAnswer{
+		UserID:     userID,
+		QuestionID: questionID,
+		Answer:     utils.ParseToJSON(answer),
+		IsCorrect:  score.Correct(),
+		Score:      score.Score,
+		MaxScore:   score.Max,
+	}
+
+	if test != nil {
+		record.TestID = test.ID
+
+		attempt, err := u.testRepo.GetOpenAttempt(ctx, test.ID, userID)
+		if errors.Is(err, domain.ErrTestNotStarted) && service.IsStaff(role) {
+			return false, u.answerRepo.Create(ctx, record)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		now := time.Now()
+		if test.DeadlinePassed(now) {
+			return false, domain.ErrDeadlinePassed
+		}
+		attempt.RefreshExpiry(test)
+		if attempt.IsExpired(now, u.timeLimitGrace) {
+			return false, domain.ErrAttemptExpired
+		}
+		if err := u.checkAttemptQuestion(ctx, test, attempt.ID, questionID); err != nil {
+			return false, err
+		}
+		if err := u.checkNotRevealed(ctx, test, userID, role, questionID); err != nil {
+			return false, err
+		}
+		record.AttemptID = attempt.ID
+
+		err = u.testRepo.SaveAnswer(ctx, &domain.UserTestAnswer{
+			TestID:     test.ID,
+			UserID:     userID,
+			QuestionID: questionID,
+			IsCorrect:  score.Correct(),
+			Score:      score.Score,
+			MaxScore:   score.Max,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return record.AttemptID > 0, u.answerRepo.Create(ctx, record)
+}
+
+// GetStats возвращает статистику вопроса по всем тестам и по каждому тесту.
+func (u *questionUsecase) GetStats(ctx context.Context, id uint) ([]*domain.QuestionStats, error) {
+	if _, err := u.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return u.itemAnalysis.GetQuestionStats(ctx, id)
 }
@@ -0,0 +1,190 @@
+package question
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"diprec_api/internal/domain"
+	"diprec_api/internal/repository/answer"
+	"diprec_api/internal/repository/course"
+	"diprec_api/internal/repository/question"
+	"diprec_api/internal/repository/test"
+	"diprec_api/internal/service"
+
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+type fakeQuestionRepo struct {
+	question.IQuestionRepository
+	question *domain.Question
+}
+
+func (r *fakeQuestionRepo) GetByID(ctx context.Context, id uint) (*domain.Question, error) {
+	copied := *r.question
+	return &copied, nil
+}
+
+// fakeTestRepo хранит лист ответов одной открытой попытки и, как и
+// настоящий репозиторий, оставляет в нём первый ответ на вопрос.
+type fakeTestRepo struct {
+	test.ITestRepository
+	test    *domain.Test
+	attempt *domain.Attempt
+	sheet   map[uint]*domain.UserTestAnswer
+}
+
+func (r *fakeTestRepo) GetStatus(ctx context.Context, testID uint) (domain.TestStatus, error) {
+	return r.test.Status, nil
+}
+
+func (r *fakeTestRepo) GetRecommendation(ctx context.Context, testID uint) (*domain.RecommendedTest, error) {
+	return nil, nil
+}
+
+func (r *fakeTestRepo) IsUserEnrolled(ctx context.Context, testID, userID uint) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeTestRepo) GetByID(ctx context.Context, id, userID uint) (*domain.Test, error) {
+	copied := *r.test
+	return &copied, nil
+}
+
+func (r *fakeTestRepo) GetGroupOverrides(ctx context.Context, testID, userID uint) ([]*domain.GroupTest, error) {
+	return nil, nil
+}
+
+func (r *fakeTestRepo) GetExtension(ctx context.Context, testID, userID uint) (*domain.TestExtension, error) {
+	return nil, domain.ErrExtensionNotFound
+}
+
+func (r *fakeTestRepo) GetQuestionPoints(ctx context.Context, testID uint) (map[uint]float64, error) {
+	return map[uint]float64{}, nil
+}
+
+func (r *fakeTestRepo) GetOpenAttempt(ctx context.Context, testID, userID uint) (*domain.Attempt, error) {
+	return r.attempt, nil
+}
+
+func (r *fakeTestRepo) GetAttemptQuestionIDs(ctx context.Context, attemptID uint) ([]uint, error) {
+	return nil, nil
+}
+
+func (r *fakeTestRepo) GetAnswers(ctx context.Context, testID, userID uint) ([]*domain.UserTestAnswer, error) {
+	answers := make([]*domain.UserTestAnswer, 0, len(r.sheet))
+	for _, answer := range r.sheet {
+		answers = append(answers, answer)
+	}
+	return answers, nil
+}
+
+func (r *fakeTestRepo) SaveAnswer(ctx context.Context, answer *domain.UserTestAnswer) error {
+	if _, ok := r.sheet[answer.QuestionID]; !ok {
+		r.sheet[answer.QuestionID] = answer
+	}
+	return nil
+}
+
+func (r *fakeTestRepo) GetCourseIDByTestID(ctx context.Context, testID uint) (uint, error) {
+	return 1, nil
+}
+
+type fakeAnswerRepo struct {
+	answer.IAnswerRepository
+	history []*domain.UserAnswer
+}
+
+func (r *fakeAnswerRepo) Create(ctx context.Context, answer *domain.UserAnswer) error {
+	r.history = append(r.history, answer)
+	return nil
+}
+
+type fakeCourseRepo struct {
+	course.ICourseRepository
+}
+
+type fakeProducer struct{}
+
+func (fakeProducer) Send(ctx context.Context, topic, key string, value interface{}) error {
+	return nil
+}
+
+func newCheckUsecase(t *testing.T, policy domain.RevealPolicy) (*questionUsecase, *fakeTestRepo) {
+	t.Helper()
+
+	q := &domain.Question{ID: 7, Type: domain.Single, Answer: jsonAnswer(t, "a")}
+	tests := &fakeTestRepo{
+		test: &domain.Test{
+			ID:           3,
+			Status:       domain.Progress,
+			RevealPolicy: policy,
+			Questions:    []*domain.Question{q},
+		},
+		attempt: &domain.Attempt{ID: 11, TestID: 3, UserID: 5, StartedAt: time.Now()},
+		sheet:   map[uint]*domain.UserTestAnswer{},
+	}
+
+	usecase := NewQuestionUsecase(
+		&fakeQuestionRepo{question: q},
+		tests,
+		&fakeAnswerRepo{},
+		service.NewAccessPolicy(&fakeCourseRepo{}, tests),
+		nil,
+		fakeProducer{},
+		zap.NewNop(),
+		0,
+	).(*questionUsecase)
+	return usecase, tests
+}
+
+func jsonAnswer(t *testing.T, value string) datatypes.JSON {
+	t.Helper()
+	return datatypes.JSON(`"` + value + `"`)
+}
+
+func TestCheckCannotRaiseScoreAfterReveal(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     domain.RevealPolicy
+		wantErr    error
+		wantAnswer bool
+	}{
+		{name: "immediately refuses re-check", policy: domain.RevealImmediately, wantErr: domain.ErrAnswerAlreadyRevealed, wantAnswer: true},
+		{name: "after submit keeps first answer", policy: domain.RevealAfterSubmit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase, repo := newCheckUsecase(t, tt.policy)
+			ctx := context.Background()
+			role := domain.RoleStudent.String()
+
+			first, err := usecase.Check(ctx, 7, 5, role, "b", 3)
+			if err != nil {
+				t.Fatalf("first Check: %v", err)
+			}
+			if first.IsCorrect {
+				t.Fatalf("first Check: wrong answer graded as correct")
+			}
+			if got := first.Answer != nil; got != tt.wantAnswer {
+				t.Fatalf("first Check: answer revealed = %v, want %v", got, tt.wantAnswer)
+			}
+
+			_, err = usecase.Check(ctx, 7, 5, role, "a", 3)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("second Check: err = %v, want %v", err, tt.wantErr)
+			}
+
+			saved := repo.sheet[7]
+			if saved == nil {
+				t.Fatalf("answer sheet has no answer for the question")
+			}
+			if saved.Score != 0 || saved.IsCorrect {
+				t.Errorf("answer sheet score = %v (correct %v), want first answer 0", saved.Score, saved.IsCorrect)
+			}
+		})
+	}
+}
@@ -4,39 +4,106 @@ import (
 	"context"
 	"diprec_api/internal/domain"
 	"diprec_api/internal/infrastructure/kafka"
+	"diprec_api/internal/pkg/utils"
+	"diprec_api/internal/repository/answer"
 	"diprec_api/internal/repository/test"
+	"diprec_api/internal/service"
+	"errors"
+	"fmt"
+	"math/rand"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// expiredAttemptsBatch - сколько просроченных попыток сдаётся за один проход.
+const expiredAttemptsBatch = 100
+
+// staleRecommendationsBatch - сколько тестов рекомендаций удаляется за один проход.
+const staleRecommendationsBatch = 100
+
+type Config struct {
+	// AllowClientProgress - режим совместимости со старыми клиентами,
+	// которые сами присылают результат теста в FinishTest.
+	AllowClientProgress bool
+	// TimeLimitGrace - допуск к лимиту времени на задержки сети.
+	TimeLimitGrace time.Duration
+	// RecommendationTTL - срок жизни теста рекомендации, если рекомендательная
+	// система его не указала. RecommendationRetention - сколько хранится
+	// завершённый тест рекомендации.
+	RecommendationTTL       time.Duration
+	RecommendationRetention time.Duration
+}
+
 type testUsecase struct {
-	repo     test.ITestRepository
-	producer kafka.IKafkaProducer
-	logger   *zap.Logger
+	repo          test.ITestRepository
+	answers       answer.IAnswerRepository
+	prerequisites *service.PrerequisiteService
+	itemAnalysis  *service.ItemAnalysisService
+	producer      kafka.IKafkaProducer
+	config        Config
+	logger        *zap.Logger
 }
 
 type ITestUsecase interface {
 	Create(ctx context.Context, test *domain.Test, courseID uint) (*domain.Test, error)
 	Get(ctx context.Context, courseID, userID uint) ([]*domain.Test, error)
-	GetByID(ctx context.Context, id, userID uint) (*domain.Test, error)
-	Update(ctx context.Context, test *domain.Test) (*domain.Test, error)
+	GetByID(ctx context.Context, id, userID uint, role string) (*domain.Test, error)
+	Update(ctx context.Context, test *domain.Test, reset ...string) (*domain.Test, error)
 	Delete(ctx context.Context, id uint) error
-	AttachQuestion(ctx context.Context, testID uint, questionID uint) error
+	AttachQuestion(ctx context.Context, testID uint, questionID uint, index *int, points float64) error
+	ReorderQuestions(ctx context.Context, testID uint, questionIDs []uint) ([]*domain.Question, error)
+	BatchQuestions(ctx context.Context, testID uint, batch *domain.QuestionBatch) ([]*domain.Question, error)
 	DetachQuestion(ctx context.Context, testID uint, questionID uint) error
-	StartTest(ctx context.Context, userTests *domain.UserTests) error
-	EndTest(ctx context.Context, userTest *domain.UserTests) error
+	StartTest(ctx context.Context, userTests *domain.UserTests) (*domain.Attempt, error)
+	EndTest(ctx context.Context, testID, userID uint, clientProgress *uint) (*domain.UserTests, error)
+	SaveDraft(ctx context.Context, attemptID, userID, questionID uint, answer interface{}) (*domain.AttemptDraft, error)
+	ResumeAttempt(ctx context.Context, attemptID, userID uint) (*domain.AttemptResume, error)
+	SubmitAttempt(ctx context.Context, attemptID, userID uint) (*domain.UserTests, error)
+	ReviewAttempt(ctx context.Context, attemptID, userID uint, role string) (*domain.AttemptReview, error)
+	FinalizeExpiredAttempts(ctx context.Context) (int, error)
+	OpenTest(ctx context.Context, testID, userID uint) (*domain.Test, error)
+	CloseTest(ctx context.Context, testID, userID uint) (*domain.Test, error)
+	GetStatusHistory(ctx context.Context, testID uint) ([]*domain.TestStatusChange, error)
+	CloseOverdueTests(ctx context.Context) (int, error)
+	PublishScheduledTests(ctx context.Context) (int, error)
+	GetSchedule(ctx context.Context, courseID uint) ([]*domain.ScheduledTransition, error)
 	CreateRecommendTest(
 		ctx context.Context,
 		test *domain.Test,
-		courseID uint,
-		userID uint,
+		recommendation *domain.RecommendedTest,
 		questionIDs []uint,
-	) (*domain.Test, error)
+	) (*domain.Test, bool, error)
+	CleanupRecommendations(ctx context.Context) (int, error)
+	Duplicate(ctx context.Context, testID, courseID uint, name string, shift time.Duration, copyQuestions bool) (*domain.Test, error)
+	Export(ctx context.Context, testID uint) (*domain.TestPackage, error)
+	Import(ctx context.Context, pkg *domain.TestPackage, options domain.TestImportOptions) (*domain.TestImport, error)
+	GetResults(ctx context.Context, testID uint) (*domain.TestResults, error)
+	GetStats(ctx context.Context, testID uint) ([]*domain.QuestionStats, error)
+	SetPools(ctx context.Context, testID uint, pools []*domain.QuestionPool) ([]*domain.QuestionPool, error)
+	GetPrerequisites(ctx context.Context, testID uint) ([]*domain.TestPrerequisite, error)
+	SetPrerequisites(ctx context.Context, testID uint, prerequisites []*domain.TestPrerequisite) ([]*domain.TestPrerequisite, error)
+	GetAnswers(ctx context.Context, testID, userID uint) ([]*domain.UserAnswer, error)
+	GetExtensions(ctx context.Context, testID uint) ([]*domain.TestExtension, error)
+	SetExtension(ctx context.Context, extension *domain.TestExtension) (*domain.TestExtension, error)
+	DeleteExtension(ctx context.Context, testID, userID uint) error
+	SetPractice(ctx context.Context, testID uint, enabled bool) (*domain.Test, error)
+	GetPractice(ctx context.Context, testID, userID uint) (*domain.Test, error)
+	CheckPractice(ctx context.Context, testID, userID, questionID uint, answer interface{}) (*domain.QuestionAnswer, error)
+	NextQuestion(ctx context.Context, attemptID, userID uint) (*domain.AdaptiveStep, error)
 }
 
-func NewTestUsecase(repo test.ITestRepository, producer kafka.IKafkaProducer, logger *zap.Logger) ITestUsecase {
-	return &testUsecase{repo, producer, logger.Named("TestUsecase")}
+func NewTestUsecase(
+	repo test.ITestRepository,
+	answers answer.IAnswerRepository,
+	prerequisites *service.PrerequisiteService,
+	itemAnalysis *service.ItemAnalysisService,
+	producer kafka.IKafkaProducer,
+	config Config,
+	logger *zap.Logger,
+) ITestUsecase {
+	return &testUsecase{repo, answers, prerequisites, itemAnalysis, producer, config, logger.Named("TestUsecase")}
 }
 
 func (u *testUsecase) Create(ctx context.Context, test *domain.Test, courseID uint) (*domain.Test, error) {
@@ -56,17 +123,66 @@ func (u *testUsecase) Get(ctx context.Context, courseID, userID uint) ([]*domain
 	return tests, nil
 }
 
-func (u *testUsecase) GetByID(ctx context.Context, id, userID uint) (*domain.Test, error) {
+// GetByID возвращает тест для пользователя. Студент не видит вопросов
+// теста, закрытого для него группой или предварительными условиями. Банк адаптивного теста студенту не
+// раскрывается: до начала попытки список вопросов пуст, а в попытке видны
+// только выданные вопросы.
+func (u *testUsecase) GetByID(ctx context.Context, id, userID uint, role string) (*domain.Test, error) {
+	test, err := u.getForUser(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if service.IsStaff(role) {
+		return test, nil
+	}
+
+	if test.Unavailable || test.Locked || (test.IsAdaptive() && test.CurrentAttemptID() == 0) {
+		test.Questions = nil
+	}
+
+	return test, nil
+}
+
+// getForUser загружает тест и подставляет эффективные для студента
+// значения дедлайна и доступности с учётом его групп.
+func (u *testUsecase) getForUser(ctx context.Context, id, userID uint) (*domain.Test, error) {
 	test, err := u.repo.GetByID(ctx, id, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := service.ApplyUserDeadline(ctx, u.repo, test, userID); err != nil {
+		return nil, err
+	}
+
+	attempts, err := u.repo.GetAttempts(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	test.UserTests.Attempts = attempts
+	// во время пересдачи ключ в тесте не показывается
+	test.AnswersRevealed = test.AnswersVisible() && test.UserTests.Status != domain.InProgress
+
+	// в тесте с пулами студент видит вопросы своей попытки, а до её
+	// начала - только прикреплённые напрямую
+	if attemptID := test.CurrentAttemptID(); test.UsesAttemptQuestions() && attemptID > 0 {
+		if err := u.useAttemptQuestions(ctx, test, attemptID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.prerequisites.Resolve(ctx, userID, []*domain.Test{test}); err != nil {
+		return nil, err
+	}
+
 	return test, nil
 }
 
-func (u *testUsecase) Update(ctx context.Context, test *domain.Test) (*domain.Test, error) {
-	if err := u.repo.Update(ctx, test); err != nil {
+// Update меняет заданные поля теста. Поля reset сбрасываются: так
+// отменяется запланированная публикация и снимаются ограничения попыток,
+// времени и поздней сдачи.
+func (u *testUsecase) Update(ctx context.Context, test *domain.Test, reset ...string) (*domain.Test, error) {
+	if err := u.repo.Update(ctx, test, reset...); err != nil {
 		return nil, err
 	}
 
@@ -81,14 +197,47 @@ func (u *testUsecase) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
-func (u *testUsecase) AttachQuestion(ctx context.Context, testID uint, questionID uint) error {
-	if err := u.repo.AttachQuestion(ctx, testID, questionID); err != nil {
+func (u *testUsecase) AttachQuestion(ctx context.Context, testID uint, questionID uint, index *int, points float64) error {
+	if err := u.repo.AttachQuestion(ctx, testID, questionID, index, points); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// ReorderQuestions задаёт порядок вопросов теста и возвращает их в новом порядке.
+func (u *testUsecase) ReorderQuestions(ctx context.Context, testID uint, questionIDs []uint) ([]*domain.Question, error) {
+	if err := u.repo.ReorderQuestions(ctx, testID, questionIDs); err != nil {
+		return nil, err
+	}
+
+	test, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return test.Questions, nil
+}
+
+// BatchQuestions применяет изменение набора вопросов и возвращает вопросы
+// теста в итоговом порядке.
+func (u *testUsecase) BatchQuestions(ctx context.Context, testID uint, batch *domain.QuestionBatch) ([]*domain.Question, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.ApplyQuestionBatch(ctx, testID, batch); err != nil {
+		return nil, err
+	}
+
+	test, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return test.Questions, nil
+}
+
 func (u *testUsecase) DetachQuestion(ctx context.Context, testID uint, questionID uint) error {
 	if err := u.repo.DetachQuestion(ctx, testID, questionID); err != nil {
 		return err
@@ -97,67 +246,1122 @@ func (u *testUsecase) DetachQuestion(ctx context.Context, testID uint, questionI
 	return nil
 }
 
-func (u *testUsecase) StartTest(ctx context.Context, userTests *domain.UserTests) error {
-	if err := u.repo.CreateUserTest(ctx, userTests); err != nil {
+// drawQuestions составляет список вопросов новой попытки теста с пулами.
+// Для теста без пулов возвращает nil - попытка использует вопросы теста.
+func (u *testUsecase) drawQuestions(ctx context.Context, test *domain.Test) ([]uint, error) {
+	if test.IsAdaptive() {
+		return u.firstAdaptiveQuestion(ctx, test.ID)
+	}
+	if !test.UsesPools() {
+		return nil, nil
+	}
+
+	candidates := make(map[uint][]uint, len(test.Pools))
+	for _, pool := range test.Pools {
+		ids, err := u.repo.GetPoolCandidates(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		candidates[pool.ID] = ids
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return test.DrawQuestions(candidates, rnd), nil
+}
+
+// firstAdaptiveQuestion - вопрос, с которого начинается адаптивная попытка:
+// самый информативный при средней способности.
+func (u *testUsecase) firstAdaptiveQuestion(ctx context.Context, testID uint) ([]uint, error) {
+	// вопросы test могли быть заменены вопросами прошлой попытки
+	test, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+	bank, err := u.adaptiveBank(ctx, test)
+	if err != nil {
+		return nil, err
+	}
+
+	first := domain.NextAdaptiveQuestion(bank, 0)
+	if first == nil {
+		return nil, nil
+	}
+	return []uint{first.ID}, nil
+}
+
+// adaptiveBank - банк адаптивного теста: прикреплённые к нему вопросы и все
+// подходящие вопросы его пулов без повторов. Вопросы из пулов стоят один
+// балл. Вопросы test должны быть загружены из связей теста.
+func (u *testUsecase) adaptiveBank(ctx context.Context, test *domain.Test) ([]*domain.Question, error) {
+	seen := make(map[uint]bool, len(test.Questions))
+	for _, question := range test.Questions {
+		seen[question.ID] = true
+	}
+
+	var pooled []uint
+	for _, pool := range test.Pools {
+		ids, err := u.repo.GetPoolCandidates(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				pooled = append(pooled, id)
+				seen[id] = true
+			}
+		}
+	}
+
+	questions, err := u.repo.GetQuestionsByIDs(ctx, pooled)
+	if err != nil {
+		return nil, err
+	}
+
+	bank := make([]*domain.Question, 0, len(test.Questions)+len(questions))
+	bank = append(bank, test.Questions...)
+	return append(bank, questions...), nil
+}
+
+// refreshExpiry подставляет в test дедлайн студента попытки и пересчитывает
+// по нему срок попытки.
+func (u *testUsecase) refreshExpiry(ctx context.Context, test *domain.Test, attempt *domain.Attempt) error {
+	if err := service.ApplyUserDeadline(ctx, u.repo, test, attempt.UserID); err != nil {
+		return err
+	}
+	attempt.RefreshExpiry(test)
+	return nil
+}
+
+// useAttemptQuestions подменяет вопросы теста зафиксированными за попыткой,
+// если они есть. Веса берутся из связей теста, вопросы из пулов стоят один балл.
+func (u *testUsecase) useAttemptQuestions(ctx context.Context, test *domain.Test, attemptID uint) error {
+	questions, err := u.repo.GetAttemptQuestions(ctx, attemptID)
+	if err != nil {
 		return err
 	}
+	if len(questions) > 0 {
+		points, err := u.repo.GetQuestionPoints(ctx, test.ID)
+		if err != nil {
+			return err
+		}
+		for _, question := range questions {
+			question.Points = points[question.ID]
+		}
+		test.Questions = questions
+	}
 
 	return nil
 }
 
+// StartTest начинает попытку прохождения теста или возвращает уже
+// открытую. Отказывает, если лимит попыток теста исчерпан или истёк
+// дедлайн с учётом групп студента.
+func (u *testUsecase) StartTest(ctx context.Context, userTests *domain.UserTests) (*domain.Attempt, error) {
+	test, err := u.getForUser(ctx, userTests.TestID, userTests.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if test.Unavailable {
+		return nil, domain.ErrTestUnavailable
+	}
+	if test.DeadlinePassed(time.Now()) {
+		return nil, domain.ErrDeadlinePassed
+	}
+	if err := u.prerequisites.Check(ctx, userTests.UserID, test); err != nil {
+		return nil, err
+	}
+
+	questionIDs, err := u.drawQuestions(ctx, test)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err := u.repo.BeginAttempt(ctx, test, userTests.UserID, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	attempt.RefreshExpiry(test)
+
+	return attempt, nil
+}
+
+// CreateRecommendTest создаёт запущенный тест рекомендации для студента.
+// Повторный вызов с тем же ключом рекомендации возвращает уже созданный
+// тест и false. Без RecommendationID ключом служит имя теста, без
+// ExpiresAt срок задаёт RecommendationTTL.
 func (u *testUsecase) CreateRecommendTest(
 	ctx context.Context,
 	test *domain.Test,
-	courseID uint,
-	userID uint,
+	recommendation *domain.RecommendedTest,
 	questionIDs []uint,
-) (*domain.Test, error) {
+) (*domain.Test, bool, error) {
+	if recommendation.RecommendationID == "" {
+		recommendation.RecommendationID = test.Name
+	}
+	if recommendation.ExpiresAt.IsZero() {
+		recommendation.ExpiresAt = time.Now().Add(u.config.RecommendationTTL)
+	}
 
 	test.Assignee = domain.Recommendation
 	test.Status = domain.Progress
 
-	if err := u.repo.CreateWithUser(ctx, test, courseID, userID); err != nil {
+	created, err := u.repo.CreateRecommended(ctx, test, recommendation, questionIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	test, err = u.repo.GetByID(ctx, recommendation.TestID, recommendation.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return test, created, nil
+}
+
+// CleanupRecommendations удаляет тесты рекомендаций, срок которых истёк или
+// которые завершены раньше, чем RecommendationRetention назад. Возвращает
+// число удалённых тестов.
+func (u *testUsecase) CleanupRecommendations(ctx context.Context) (int, error) {
+	now := time.Now()
+	recommendations, err := u.repo.GetStaleRecommendations(ctx, now, now.Add(-u.config.RecommendationRetention), staleRecommendationsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, recommendation := range recommendations {
+		if err := u.repo.DeleteRecommended(ctx, recommendation); err != nil {
+			u.logger.Warn("cannot delete recommended test",
+				zap.Uint("testID", recommendation.TestID), zap.Error(err))
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// EndTest завершает открытую попытку студента. Результат попытки считается
+// сервером по листу ответов, присланных через проверку вопросов, а итог по
+// тесту - по политике оценивания теста. Значение от клиента принимается
+// только в режиме совместимости AllowClientProgress. Если время попытки
+// уже вышло, она сдаётся с ответами, принятыми до истечения срока.
+func (u *testUsecase) EndTest(ctx context.Context, testID, userID uint, clientProgress *uint) (*domain.UserTests, error) {
+	if clientProgress != nil && !u.config.AllowClientProgress {
+		return nil, domain.ErrClientProgressRejected
+	}
+
+	attempt, err := u.repo.GetOpenAttempt(ctx, testID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	test, err := u.repo.GetByID(ctx, testID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientProgress != nil {
+		u.logger.Warn("legacy client progress accepted", zap.Uint("testID", testID), zap.Uint("userID", userID))
+		attempt.Score = *clientProgress
+		return u.completeAttempt(ctx, test, attempt)
+	}
+
+	return u.scoreAndCompleteAttempt(ctx, test, attempt)
+}
+
+// getOwnAttempt возвращает незавершённую попытку студента. Чужая попытка
+// не отличается от несуществующей.
+func (u *testUsecase) getOwnAttempt(ctx context.Context, attemptID, userID uint) (*domain.Attempt, error) {
+	attempt, err := u.repo.GetAttempt(ctx, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != userID {
+		return nil, domain.ErrAttemptNotFound
+	}
+	if attempt.Status != domain.InProgress {
+		return nil, domain.ErrTestNotStarted
+	}
+
+	return attempt, nil
+}
+
+// SaveDraft сохраняет ответ на вопрос попытки без проверки. Повторное
+// сохранение перезаписывает черновик.
+func (u *testUsecase) SaveDraft(ctx context.Context, attemptID, userID, questionID uint, answer interface{}) (*domain.AttemptDraft, error) {
+	attempt, err := u.getOwnAttempt(ctx, attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	test, err := u.repo.GetByID(ctx, attempt.TestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.refreshExpiry(ctx, test, attempt); err != nil {
+		return nil, err
+	}
+	if attempt.IsExpired(time.Now(), u.config.TimeLimitGrace) {
+		return nil, domain.ErrAttemptExpired
+	}
+	if err := u.useAttemptQuestions(ctx, test, attempt.ID); err != nil {
+		return nil, err
+	}
+	if !test.HasQuestion(questionID) {
+		return nil, domain.ErrQuestionNotInAttempt
+	}
+
+	draft := &domain.AttemptDraft{
+		AttemptID:  attempt.ID,
+		QuestionID: questionID,
+		Answer:     utils.ParseToJSON(answer),
+	}
+	if err := u.repo.SaveDraft(ctx, draft); err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
+
+// ResumeAttempt возвращает незавершённую попытку с вопросами и черновиками.
+func (u *testUsecase) ResumeAttempt(ctx context.Context, attemptID, userID uint) (*domain.AttemptResume, error) {
+	attempt, err := u.getOwnAttempt(ctx, attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	test, err := u.getForUser(ctx, attempt.TestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	attempt.RefreshExpiry(test)
+
+	drafts, err := u.repo.GetDrafts(ctx, attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AttemptResume{Attempt: attempt, Test: test, Drafts: drafts}, nil
+}
+
+// SubmitAttempt проверяет черновики и сдаёт попытку.
+func (u *testUsecase) SubmitAttempt(ctx context.Context, attemptID, userID uint) (*domain.UserTests, error) {
+	attempt, err := u.getOwnAttempt(ctx, attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	test, err := u.repo.GetByID(ctx, attempt.TestID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.scoreAndCompleteAttempt(ctx, test, attempt)
+}
+
+// NextQuestion выдаёт следующий вопрос адаптивной попытки. Оценка
+// способности пересчитывается по проверенным ответам и сохраняется в
+// попытке. Пока на выданный вопрос нет ответа, возвращается он же. Когда
+// срабатывает правило остановки, попытка завершается с оценкой способности
+// в качестве результата.
+func (u *testUsecase) NextQuestion(ctx context.Context, attemptID, userID uint) (*domain.AdaptiveStep, error) {
+	attempt, err := u.getOwnAttempt(ctx, attemptID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	test, err := u.repo.GetByID(ctx, attempt.TestID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !test.IsAdaptive() {
+		return nil, domain.ErrTestNotAdaptive
+	}
+	if err := u.refreshExpiry(ctx, test, attempt); err != nil {
+		return nil, err
+	}
+	if attempt.IsExpired(time.Now(), u.config.TimeLimitGrace) {
+		return nil, domain.ErrAttemptExpired
+	}
+	if test.Questions, err = u.adaptiveBank(ctx, test); err != nil {
+		return nil, err
+	}
+
+	served, err := u.repo.GetAttemptQuestionIDs(ctx, attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := u.repo.GetAnswers(ctx, test.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	answered, pending, remaining := test.AdaptiveProgress(served, answers)
+	estimate := domain.EstimateAbility(answered, answers)
+	attempt.SetAbility(estimate)
+	if err := u.repo.SaveAbility(ctx, attempt); err != nil {
 		return nil, err
 	}
-	// прикрепляем вопросы
-	for _, qid := range questionIDs {
-		if err := u.repo.AttachQuestion(ctx, test.ID, qid); err != nil {
+
+	step := &domain.AdaptiveStep{Attempt: attempt, Estimate: estimate}
+	if pending != nil {
+		step.Question = pending
+		step.Number = len(answered) + 1
+		return step, nil
+	}
+
+	if test.AdaptiveStopped(estimate, len(remaining)) {
+		result, err := u.scoreAndCompleteAttempt(ctx, test, attempt)
+		if err != nil {
 			return nil, err
 		}
+		step.Result = result
+		return step, nil
 	}
-	return test, nil
+
+	next := domain.NextAdaptiveQuestion(remaining, estimate.Ability)
+	if err := u.repo.AddAttemptQuestion(ctx, attempt.ID, next.ID); err != nil {
+		return nil, err
+	}
+	step.Question = next
+	step.Number = len(served) + 1
+
+	return step, nil
+}
+
+// ReviewAttempt возвращает ответы завершённой попытки рядом с правильными.
+// Студент видит только свои попытки и только когда это разрешает политика
+// показа ответов теста.
+func (u *testUsecase) ReviewAttempt(ctx context.Context, attemptID, userID uint, role string) (*domain.AttemptReview, error) {
+	attempt, err := u.repo.GetAttempt(ctx, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	// чужую попытку до сюда пропускает только персонал курса (AttemptAccess)
+	if !service.IsStaff(role) && attempt.UserID != userID {
+		return nil, domain.ErrAttemptNotFound
+	}
+	if attempt.Status != domain.Completed {
+		return nil, domain.ErrTestNotStarted
+	}
+
+	test, err := u.repo.GetByID(ctx, attempt.TestID, attempt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !service.IsStaff(role) && !test.AnswersVisible() {
+		return nil, domain.ErrAnswersHidden
+	}
+	if err := u.useAttemptQuestions(ctx, test, attempt.ID); err != nil {
+		return nil, err
+	}
+
+	answers, err := u.answers.GetByAttempt(ctx, attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AttemptReview{
+		Attempt:   attempt,
+		Questions: test.Questions,
+		Answers:   domain.LatestAnswers(answers),
+	}, nil
 }
 
-func (u *testUsecase) EndTest(ctx context.Context, userTest *domain.UserTests) error {
-	if err := u.repo.UpdateUserTest(ctx, userTest); err != nil {
+// gradeDrafts проверяет черновики попытки и заносит их в лист ответов и
+// историю. Черновик пропускается, если на вопрос уже ответили через
+// проверку: засчитывается первый ответ. Вопросы теста должны быть загружены.
+func (u *testUsecase) gradeDrafts(ctx context.Context, test *domain.Test, attempt *domain.Attempt) error {
+	drafts, err := u.repo.GetDrafts(ctx, attempt.ID)
+	if err != nil || len(drafts) == 0 {
 		return err
 	}
 
-	test, err := u.repo.GetByID(ctx, userTest.TestID, userTest.UserID)
+	answers, err := u.repo.GetAnswers(ctx, attempt.TestID, attempt.UserID)
 	if err != nil {
 		return err
 	}
+	answered := make(map[uint]bool, len(answers))
+	for _, answer := range answers {
+		answered[answer.QuestionID] = true
+	}
 
-	if test.Assignee == domain.Recommendation {
-		return nil
+	questions := make(map[uint]*domain.Question, len(test.Questions))
+	for _, question := range test.Questions {
+		questions[question.ID] = question
 	}
 
-	courseID, err := u.repo.GetCourseIDByTestID(ctx, userTest.TestID)
-	if err != nil {
-		u.logger.Warn("cannot lookup course for test", zap.Uint("testID", userTest.TestID), zap.Error(err))
-	} else {
-		msg := map[string]interface{}{
-			"user_id":   int(userTest.UserID),
-			"test_id":   int(userTest.TestID),
-			"course_id": int(courseID),
+	var checks []*domain.UserAnswerCheck
+	for _, draft := range drafts {
+		question, ok := questions[draft.QuestionID]
+		if !ok {
+			continue
+		}
+		if answered[draft.QuestionID] {
+			continue
 		}
-		_ = u.producer.Send(
-			ctx,
-			domain.TopicUserTest,
-			strconv.Itoa(int(userTest.UserID)),
-			msg,
-		)
-	}
 
-	return nil
+		answer := utils.ParseJSONInterface(draft.Answer)
+		score := question.Grade(answer)
+
+		err := u.repo.SaveAnswer(ctx, &domain.UserTestAnswer{
+			TestID:     attempt.TestID,
+			UserID:     attempt.UserID,
+			QuestionID: question.ID,
+			IsCorrect:  score.Correct(),
+			Score:      score.Score,
+			MaxScore:   score.Max,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = u.answers.Create(ctx, &domain.UserAnswer{
+			UserID:     attempt.UserID,
+			TestID:     attempt.TestID,
+			AttemptID:  attempt.ID,
+			QuestionID: question.ID,
+			Answer:     draft.Answer,
+			IsCorrect:  score.Correct(),
+			Score:      score.Score,
+			MaxScore:   score.Max,
+		})
+		if err != nil {
+			return err
+		}
+
+		checks = append(checks, &domain.UserAnswerCheck{
+			QuestionID: question.ID,
+			Title:      question.Title,
+			Type:       question.Type.String(),
+			Variants:   question.Variants,
+			Answer:     question.Answer,
+			UserID:     attempt.UserID,
+			IsCorrect:  score.Correct(),
+			Score:      score.Score,
+			MaxScore:   score.Max,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	u.publishAnswers(ctx, test, checks)
+	return nil
+}
+
+// publishAnswers отправляет проверенные ответы в рекомендательную систему,
+// как это делает проверка отдельного вопроса.
+func (u *testUsecase) publishAnswers(ctx context.Context, test *domain.Test, checks []*domain.UserAnswerCheck) {
+	if len(checks) == 0 || test.Assignee == domain.Recommendation {
+		return
+	}
+
+	courseID, err := u.repo.GetCourseIDByTestID(ctx, test.ID)
+	if err != nil {
+		u.logger.Warn("cannot lookup course for test", zap.Uint("testID", test.ID), zap.Error(err))
+		return
+	}
+
+	for _, check := range checks {
+		check.CourseID = courseID
+		_ = u.producer.Send(
+			ctx,
+			domain.TopicUserAnswers,
+			strconv.Itoa(int(check.UserID)),
+			check,
+		)
+	}
+}
+
+// FinalizeExpiredAttempts сдаёт брошенные попытки, время которых истекло,
+// с результатом, посчитанным сервером. Возвращает число сданных попыток.
+func (u *testUsecase) FinalizeExpiredAttempts(ctx context.Context) (int, error) {
+	attempts, err := u.repo.GetExpiredAttempts(ctx, time.Now().Add(-u.config.TimeLimitGrace), expiredAttemptsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	return u.finalizeAttempts(ctx, attempts), nil
+}
+
+// OpenTest запускает тест по команде преподавателя userID.
+func (u *testUsecase) OpenTest(ctx context.Context, testID, userID uint) (*domain.Test, error) {
+	return u.changeStatus(ctx, testID, domain.Progress, domain.ReasonManual, &userID)
+}
+
+// CloseTest завершает тест по команде преподавателя userID.
+func (u *testUsecase) CloseTest(ctx context.Context, testID, userID uint) (*domain.Test, error) {
+	return u.changeStatus(ctx, testID, domain.Ended, domain.ReasonManual, &userID)
+}
+
+// CloseOverdueTests завершает запущенные тесты, дедлайн которых истёк.
+// Возвращает число закрытых тестов.
+func (u *testUsecase) CloseOverdueTests(ctx context.Context) (int, error) {
+	ids, err := u.repo.GetOverdueTestIDs(ctx, time.Now().Add(-u.config.TimeLimitGrace))
+	if err != nil {
+		return 0, err
+	}
+
+	return u.changeStatuses(ctx, ids, domain.Ended, domain.ReasonDeadline), nil
+}
+
+// PublishScheduledTests запускает черновики, время публикации которых
+// наступило. Пропущенные, пока сервис был остановлен, запускаются при
+// первом проходе после старта. Возвращает число запущенных тестов.
+func (u *testUsecase) PublishScheduledTests(ctx context.Context) (int, error) {
+	ids, err := u.repo.GetDueDraftIDs(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return u.changeStatuses(ctx, ids, domain.Progress, domain.ReasonSchedule), nil
+}
+
+func (u *testUsecase) GetStatusHistory(ctx context.Context, testID uint) ([]*domain.TestStatusChange, error) {
+	if _, err := u.repo.GetStatus(ctx, testID); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetStatusHistory(ctx, testID)
+}
+
+// changeStatuses выполняет автоматический переход для каждого теста и
+// возвращает число успешных. Тесты, для которых переход недопустим,
+// пропускаются с предупреждением.
+func (u *testUsecase) changeStatuses(ctx context.Context, ids []uint, to domain.TestStatus, reason domain.StatusChangeReason) int {
+	var changed int
+	for _, id := range ids {
+		if _, err := u.changeStatus(ctx, id, to, reason, nil); err != nil {
+			u.logger.Warn("cannot change test status",
+				zap.Uint("testID", id),
+				zap.String("to", to.String()),
+				zap.String("reason", reason.String()),
+				zap.Error(err),
+			)
+			continue
+		}
+		changed++
+	}
+
+	return changed
+}
+
+// changeStatus проверяет переход по машине состояний теста, сохраняет его
+// в истории и при завершении теста сдаёт незавершённые попытки.
+func (u *testUsecase) changeStatus(ctx context.Context, testID uint, to domain.TestStatus, reason domain.StatusChangeReason, changedBy *uint) (*domain.Test, error) {
+	test, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := test.CheckTransition(to); err != nil {
+		return nil, err
+	}
+
+	changed, err := u.repo.ChangeStatus(ctx, &domain.TestStatusChange{
+		TestID:    testID,
+		From:      test.Status,
+		To:        to,
+		Reason:    reason,
+		ChangedBy: changedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		// статус успели сменить параллельно
+		return nil, fmt.Errorf("%w: %s -> %s", domain.ErrInvalidStatusTransition, test.Status, to)
+	}
+	test.Status = to
+
+	if to == domain.Ended {
+		u.onTestEnded(ctx, testID)
+	}
+
+	return test, nil
+}
+
+// GetSchedule возвращает предстоящие автоматические публикации и закрытия
+// тестов курса.
+func (u *testUsecase) GetSchedule(ctx context.Context, courseID uint) ([]*domain.ScheduledTransition, error) {
+	tests, err := u.repo.Get(ctx, courseID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	testIDs := make([]uint, len(tests))
+	for i, test := range tests {
+		testIDs[i] = test.ID
+	}
+	groupDeadlines, err := u.repo.GetGroupDeadlines(ctx, testIDs)
+	if err != nil {
+		return nil, err
+	}
+	extensionDeadlines, err := u.repo.GetExtensionDeadlines(ctx, testIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// тест закрывается, когда истёк и его дедлайн, и все дедлайны групп
+	// вместе с окном поздней сдачи, и все индивидуальные продления
+	closesAt := make(map[uint]time.Time, len(tests))
+	for _, test := range tests {
+		if !test.HasDeadline() {
+			continue
+		}
+		if deadline, ok := groupDeadlines[test.ID]; ok && deadline.After(test.Deadline) {
+			test.Deadline = deadline
+		}
+		closesAt[test.ID] = test.ClosesAt()
+		if deadline, ok := extensionDeadlines[test.ID]; ok && deadline.After(closesAt[test.ID]) {
+			closesAt[test.ID] = deadline
+		}
+	}
+
+	return domain.UpcomingTransitions(tests, closesAt, time.Now()), nil
+}
+
+// onTestEnded сдаёт незавершённые попытки закрытого теста и публикует
+// событие о его завершении.
+func (u *testUsecase) onTestEnded(ctx context.Context, testID uint) {
+	attempts, err := u.repo.GetOpenAttemptsByTest(ctx, testID)
+	if err != nil {
+		u.logger.Warn("cannot load open attempts of ended test", zap.Uint("testID", testID), zap.Error(err))
+	}
+	finalized := u.finalizeAttempts(ctx, attempts)
+
+	msg := map[string]interface{}{
+		"test_id":   int(testID),
+		"finalized": finalized,
+		"ended_at":  time.Now(),
+	}
+	if courseID, err := u.repo.GetCourseIDByTestID(ctx, testID); err == nil {
+		msg["course_id"] = int(courseID)
+	}
+	_ = u.producer.Send(
+		ctx,
+		domain.TopicTestEnded,
+		strconv.Itoa(int(testID)),
+		msg,
+	)
+}
+
+// finalizeAttempts сдаёт попытки с результатом по листу ответов и
+// возвращает число сданных.
+func (u *testUsecase) finalizeAttempts(ctx context.Context, attempts []*domain.Attempt) int {
+	var finalized int
+	for _, attempt := range attempts {
+		test, err := u.repo.GetByID(ctx, attempt.TestID, attempt.UserID)
+		if err != nil {
+			u.logger.Warn("cannot load test for attempt", zap.Uint("attemptID", attempt.ID), zap.Error(err))
+			continue
+		}
+
+		// после продления или переноса дедлайна попытка может быть ещё открыта
+		if err := u.refreshExpiry(ctx, test, attempt); err != nil {
+			u.logger.Warn("cannot refresh attempt expiry", zap.Uint("attemptID", attempt.ID), zap.Error(err))
+			continue
+		}
+		if !attempt.IsExpired(time.Now(), u.config.TimeLimitGrace) {
+			if err := u.repo.UpdateAttemptExpiry(ctx, attempt); err != nil {
+				u.logger.Warn("cannot update attempt expiry", zap.Uint("attemptID", attempt.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		if _, err := u.scoreAndCompleteAttempt(ctx, test, attempt); err != nil {
+			// попытку мог уже сдать сам студент или другая реплика
+			if !errors.Is(err, domain.ErrTestNotStarted) {
+				u.logger.Warn("cannot finalize attempt", zap.Uint("attemptID", attempt.ID), zap.Error(err))
+			}
+			continue
+		}
+		finalized++
+	}
+
+	return finalized
+}
+
+func (u *testUsecase) scoreAndCompleteAttempt(ctx context.Context, test *domain.Test, attempt *domain.Attempt) (*domain.UserTests, error) {
+	if err := u.useAttemptQuestions(ctx, test, attempt.ID); err != nil {
+		return nil, err
+	}
+	if err := u.gradeDrafts(ctx, test, attempt); err != nil {
+		return nil, err
+	}
+
+	answers, err := u.repo.GetAnswers(ctx, attempt.TestID, attempt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	total := test.Score(answers)
+	attempt.Score = total.Percent()
+	attempt.Points, attempt.MaxPoints = total.Score, total.Max
+
+	// результат адаптивного теста - оценка способности по выданным вопросам
+	if test.IsAdaptive() {
+		estimate := domain.EstimateAbility(test.Questions, answers)
+		attempt.SetAbility(estimate)
+		attempt.Score = estimate.Percent()
+	}
+
+	return u.completeAttempt(ctx, test, attempt)
+}
+
+// completeAttempt закрывает попытку с уже выставленным attempt.Score,
+// снижая его за позднюю сдачу, пересчитывает итог по тесту и уведомляет
+// рекомендательную систему.
+func (u *testUsecase) completeAttempt(ctx context.Context, test *domain.Test, attempt *domain.Attempt) (*domain.UserTests, error) {
+	if err := u.refreshExpiry(ctx, test, attempt); err != nil {
+		return nil, err
+	}
+	if late, penalty := test.Lateness(attempt.SubmittedAt(time.Now())); late {
+		attempt.ApplyLatePenalty(penalty)
+	}
+
+	userTest, err := u.repo.FinishAttempt(ctx, attempt, test.ScoringPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	if test.Assignee == domain.Recommendation {
+		return userTest, nil
+	}
+
+	courseID, err := u.repo.GetCourseIDByTestID(ctx, test.ID)
+	if err != nil {
+		u.logger.Warn("cannot lookup course for test", zap.Uint("testID", test.ID), zap.Error(err))
+	} else {
+		msg := map[string]interface{}{
+			"user_id":   int(attempt.UserID),
+			"test_id":   int(test.ID),
+			"course_id": int(courseID),
+		}
+		_ = u.producer.Send(
+			ctx,
+			domain.TopicUserTest,
+			strconv.Itoa(int(attempt.UserID)),
+			msg,
+		)
+	}
+
+	return userTest, nil
+}
+
+// Duplicate копирует тест в курс courseID под именем name черновиком.
+// Дедлайн и время публикации сдвигаются на shift.
+func (u *testUsecase) Duplicate(ctx context.Context, testID, courseID uint, name string, shift time.Duration, copyQuestions bool) (*domain.Test, error) {
+	source, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate := source.Duplicate(name, shift)
+	if err := u.repo.Duplicate(ctx, source, duplicate, courseID, copyQuestions); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(ctx, duplicate.ID, 0)
+}
+
+// Export собирает пакет теста с вопросами, в том числе вопросами из
+// наборов пулов.
+func (u *testUsecase) Export(ctx context.Context, testID uint) (*domain.TestPackage, error) {
+	test, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	poolQuestions, err := u.repo.GetQuestionsByIDs(ctx, test.PoolQuestionIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewTestPackage(test, poolQuestions, time.Now()), nil
+}
+
+// Import создаёт по пакету черновик теста в курсе и сообщает, как
+// сопоставлен каждый вопрос пакета.
+func (u *testUsecase) Import(ctx context.Context, pkg *domain.TestPackage, options domain.TestImportOptions) (*domain.TestImport, error) {
+	if err := pkg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := pkg.CheckMapping(options.Mapping); err != nil {
+		return nil, err
+	}
+
+	name := options.Name
+	if name == "" {
+		name = pkg.Test.Name
+	}
+	test := pkg.NewTest(name)
+	if options.Deadline != nil {
+		test.Deadline = *options.Deadline
+	}
+
+	questions, err := u.repo.Import(ctx, pkg, test, options)
+	if err != nil {
+		return nil, err
+	}
+
+	imported, err := u.repo.GetByID(ctx, test.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TestImport{Test: imported, Questions: questions}, nil
+}
+
+// GetResults собирает результаты теста по всем записанным на курс студентам,
+// включая тех, кто ещё не приступал.
+func (u *testUsecase) GetResults(ctx context.Context, testID uint) (*domain.TestResults, error) {
+	if _, err := u.repo.GetStatus(ctx, testID); err != nil {
+		return nil, err
+	}
+
+	students, err := u.repo.GetEnrolledStudents(ctx, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	userTests, err := u.repo.GetUserTestsByTest(ctx, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := u.repo.GetAttemptsByTest(ctx, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TestResults{
+		TestID:    testID,
+		Students:  students,
+		UserTests: userTests,
+		Attempts:  attempts,
+	}, nil
+}
+
+// GetStats возвращает статистику вопросов теста на момент последнего расчёта.
+func (u *testUsecase) GetStats(ctx context.Context, testID uint) ([]*domain.QuestionStats, error) {
+	if _, err := u.repo.GetStatus(ctx, testID); err != nil {
+		return nil, err
+	}
+
+	return u.itemAnalysis.GetTestStats(ctx, testID)
+}
+
+// SetPools заменяет правила выборки вопросов теста. В каждом пуле должно
+// хватать подходящих вопросов.
+func (u *testUsecase) SetPools(ctx context.Context, testID uint, pools []*domain.QuestionPool) ([]*domain.QuestionPool, error) {
+	if _, err := u.repo.GetByID(ctx, testID, 0); err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		if err := pool.Validate(); err != nil {
+			return nil, err
+		}
+
+		pool.TestID = testID
+		candidates, err := u.repo.GetPoolCandidates(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		if uint(len(candidates)) < pool.Count {
+			return nil, fmt.Errorf("%w: нужно %d, есть %d", domain.ErrPoolTooSmall, pool.Count, len(candidates))
+		}
+	}
+
+	if err := u.repo.SetPools(ctx, testID, pools); err != nil {
+		return nil, err
+	}
+
+	return pools, nil
+}
+
+func (u *testUsecase) GetPrerequisites(ctx context.Context, testID uint) ([]*domain.TestPrerequisite, error) {
+	prerequisites, err := u.prerequisites.GetTestPrerequisites(ctx, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+func (u *testUsecase) SetPrerequisites(ctx context.Context, testID uint, prerequisites []*domain.TestPrerequisite) ([]*domain.TestPrerequisite, error) {
+	if _, err := u.repo.GetByID(ctx, testID, 0); err != nil {
+		return nil, err
+	}
+
+	if err := u.prerequisites.SetTestPrerequisites(ctx, testID, prerequisites); err != nil {
+		return nil, err
+	}
+
+	return u.prerequisites.GetTestPrerequisites(ctx, testID)
+}
+
+// GetAnswers возвращает историю ответов по тесту. Нулевой userID означает
+// всех студентов.
+func (u *testUsecase) GetAnswers(ctx context.Context, testID, userID uint) ([]*domain.UserAnswer, error) {
+	answers, err := u.answers.GetByTest(ctx, testID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return answers, nil
+}
+
+func (u *testUsecase) GetExtensions(ctx context.Context, testID uint) ([]*domain.TestExtension, error) {
+	if _, err := u.repo.GetStatus(ctx, testID); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetExtensions(ctx, testID)
+}
+
+// SetExtension продлевает дедлайн теста студенту, записанному на его курс.
+// Продление заменяет дедлайны теста и групп и отменяет штраф за опоздание.
+// Срок открытой попытки студента пересчитывается по новому дедлайну.
+func (u *testUsecase) SetExtension(ctx context.Context, extension *domain.TestExtension) (*domain.TestExtension, error) {
+	test, err := u.repo.GetByID(ctx, extension.TestID, extension.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled, err := u.repo.IsUserEnrolled(ctx, extension.TestID, extension.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, domain.ErrStudentNotEnrolled
+	}
+
+	attempt, err := u.repo.GetOpenAttempt(ctx, extension.TestID, extension.UserID)
+	if errors.Is(err, domain.ErrTestNotStarted) {
+		attempt = nil
+	} else if err != nil {
+		return nil, err
+	}
+	if attempt != nil {
+		if err := service.ApplyUserDeadline(ctx, u.repo, test, extension.UserID); err != nil {
+			return nil, err
+		}
+		test.ApplyExtension(extension)
+		attempt.RefreshExpiry(test)
+	}
+
+	if err := u.repo.SetExtension(ctx, extension, attempt); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetExtension(ctx, extension.TestID, extension.UserID)
+}
+
+func (u *testUsecase) DeleteExtension(ctx context.Context, testID, userID uint) error {
+	return u.repo.DeleteExtension(ctx, testID, userID)
+}
+
+// SetPractice включает или выключает практику по тесту. Включить её можно
+// только у завершённого теста.
+func (u *testUsecase) SetPractice(ctx context.Context, testID uint, enabled bool) (*domain.Test, error) {
+	test, err := u.repo.GetByID(ctx, testID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if enabled && test.Status != domain.Ended {
+		return nil, domain.ErrTestNotEnded
+	}
+	if enabled && test.RevealPolicy == domain.RevealNever {
+		return nil, domain.ErrPracticeAnswersHidden
+	}
+
+	if err := u.repo.Update(ctx, &domain.Test{ID: testID, Practice: &enabled}); err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(ctx, testID, 0)
+}
+
+// GetPractice возвращает тест с вопросами для практики: прикреплёнными и
+// из наборов пулов, каждый по одному разу.
+func (u *testUsecase) GetPractice(ctx context.Context, testID, userID uint) (*domain.Test, error) {
+	test, err := u.repo.GetByID(ctx, testID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !test.PracticeAvailable() {
+		return nil, domain.ErrPracticeUnavailable
+	}
+
+	poolQuestions, err := u.repo.GetQuestionsByIDs(ctx, test.PoolQuestionIDs())
+	if err != nil {
+		return nil, err
+	}
+	test.AddQuestions(poolQuestions)
+
+	return test, nil
+}
+
+// CheckPractice проверяет ответ на вопрос в режиме практики и сразу
+// возвращает правильный ответ. Ни попытки, ни результат теста, ни история
+// ответов не меняются, а событие уходит в отдельный топик с пометкой практики.
+func (u *testUsecase) CheckPractice(ctx context.Context, testID, userID, questionID uint, answer interface{}) (*domain.QuestionAnswer, error) {
+	test, err := u.GetPractice(ctx, testID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var question *domain.Question
+	for _, q := range test.Questions {
+		if q.ID == questionID {
+			question = q
+			break
+		}
+	}
+	if question == nil {
+		return nil, fmt.Errorf("%w: %d", domain.ErrQuestionNotFound, questionID)
+	}
+
+	score := question.Grade(answer)
+	u.publishPracticeAnswer(ctx, test, &domain.UserAnswerCheck{
+		QuestionID: question.ID,
+		Title:      question.Title,
+		Type:       question.Type.String(),
+		Variants:   question.Variants,
+		Answer:     question.Answer,
+		UserID:     userID,
+		IsCorrect:  score.Correct(),
+		Score:      score.Score,
+		MaxScore:   score.Max,
+		Timestamp:  time.Now(),
+		Practice:   true,
+	})
+
+	return &domain.QuestionAnswer{
+		IsCorrect: score.Correct(),
+		Score:     score.Score,
+		MaxScore:  score.Max,
+		Message:   utils.GenerateFeedbackMessage(score.Correct()),
+		Answer:    question.Answer,
+	}, nil
+}
+
+func (u *testUsecase) publishPracticeAnswer(ctx context.Context, test *domain.Test, check *domain.UserAnswerCheck) {
+	if test.Assignee == domain.Recommendation {
+		return
+	}
+
+	courseID, err := u.repo.GetCourseIDByTestID(ctx, test.ID)
+	if err != nil {
+		u.logger.Warn("cannot lookup course for test", zap.Uint("testID", test.ID), zap.Error(err))
+		return
+	}
+
+	check.CourseID = courseID
+	_ = u.producer.Send(
+		ctx,
+		domain.TopicPracticeAnswers,
+		strconv.Itoa(int(check.UserID)),
+		check,
+	)
 }